@@ -0,0 +1,75 @@
+package sflow
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// AddressType is the type of the agent address of a datagram,
+// which determines its length.
+type AddressType uint32
+
+const (
+	AddressTypeUnknown AddressType = 0 // no address
+	AddressTypeIPv4    AddressType = 1
+	AddressTypeIPv6    AddressType = 2
+)
+
+var ErrInvalidAddress = errors.New("sflow: invalid agent address")
+
+func (t AddressType) String() string {
+	switch t {
+	case AddressTypeUnknown:
+		return "UNKNOWN"
+	case AddressTypeIPv4:
+		return "IPv4"
+	case AddressTypeIPv6:
+		return "IPv6"
+	}
+
+	return fmt.Sprintf("AddressType(%d)", uint32(t))
+}
+
+// length returns the length of addresses of type t,
+// or false if t isn't supported.
+func (t AddressType) length() (int, bool) {
+	switch t {
+	case AddressTypeUnknown:
+		return 0, true
+	case AddressTypeIPv4:
+		return net.IPv4len, true
+	case AddressTypeIPv6:
+		return net.IPv6len, true
+	}
+
+	return 0, false
+}
+
+// AddressTypeError is returned when decoding a datagram
+// whose agent address type isn't supported.
+type AddressTypeError struct {
+	AddressType AddressType
+}
+
+func (e *AddressTypeError) Error() string {
+	return fmt.Sprintf("sflow: unsupported agent address type %d", uint32(e.AddressType))
+}
+
+// addressOf returns the type and bytes of ip as an agent address.
+// An empty address is of type AddressTypeUnknown.
+func addressOf(ip net.IP) (AddressType, []byte, error) {
+	if len(ip) == 0 {
+		return AddressTypeUnknown, nil, nil
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return AddressTypeIPv4, ip4, nil
+	}
+
+	if len(ip) == net.IPv6len {
+		return AddressTypeIPv6, ip, nil
+	}
+
+	return 0, nil, ErrInvalidAddress
+}
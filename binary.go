@@ -3,6 +3,7 @@ package sflow
 import (
 	"encoding/binary"
 	"errors"
+	"io"
 	"math"
 )
 
@@ -13,9 +14,12 @@ var (
 
 // readFields reads big-endian encoded numbers from b into
 // elements of fields, which should be pointers to numbers,
-// e.g. *uint32 or *float32.
-func readFields(b []byte, fields []interface{}) error {
-	for len(b) > 0 && len(fields) > 0 {
+// e.g. *uint32 or *float32, and returns the number of bytes read.
+// It returns ErrInvalidSliceLength if b is too short for fields.
+func readFields(b []byte, fields []interface{}) (int, error) {
+	n := 0
+
+	for len(fields) > 0 {
 		field := fields[0]
 		size := 0
 
@@ -23,7 +27,7 @@ func readFields(b []byte, fields []interface{}) error {
 		case *int8, *uint8:
 			// 1 byte
 			if len(b) < 1 {
-				return ErrInvalidSliceLength
+				return n, ErrInvalidSliceLength
 			}
 			size = 1
 
@@ -37,7 +41,7 @@ func readFields(b []byte, fields []interface{}) error {
 		case *int16, *uint16:
 			// 2 bytes
 			if len(b) < 2 {
-				return ErrInvalidSliceLength
+				return n, ErrInvalidSliceLength
 			}
 			size = 2
 
@@ -53,7 +57,7 @@ func readFields(b []byte, fields []interface{}) error {
 		case *float32, *int32, *uint32:
 			// 4 bytes
 			if len(b) < 4 {
-				return ErrInvalidSliceLength
+				return n, ErrInvalidSliceLength
 			}
 			size = 4
 
@@ -71,7 +75,7 @@ func readFields(b []byte, fields []interface{}) error {
 		case *float64, *int64, *uint64:
 			// 8 bytes
 			if len(b) < 8 {
-				return ErrInvalidSliceLength
+				return n, ErrInvalidSliceLength
 			}
 			size = 8
 
@@ -87,12 +91,46 @@ func readFields(b []byte, fields []interface{}) error {
 			}
 
 		default:
-			return ErrInvalidFieldType
+			return n, ErrInvalidFieldType
 		}
 
 		b = b[size:]
 		fields = fields[1:]
+		n += size
+	}
+
+	return n, nil
+}
+
+// readString reads an XDR string from b into s and returns
+// the number of bytes consumed, including padding.
+func readString(b []byte, s *string) (int, error) {
+	if len(b) < 4 {
+		return 0, ErrInvalidSliceLength
+	}
+
+	length := int(binary.BigEndian.Uint32(b))
+	padding := (4 - length%4) % 4
+
+	if length > len(b)-4-padding {
+		return 0, ErrInvalidSliceLength
 	}
 
-	return nil
+	*s = string(b[4 : 4+length])
+
+	return 4 + length + padding, nil
+}
+
+// writeString writes s to w as an XDR string: a 32-bit length
+// followed by the bytes of s, padded to a multiple of 4 bytes.
+func writeString(w io.Writer, s string) error {
+	err := binary.Write(w, binary.BigEndian, uint32(len(s)))
+	if err != nil {
+		return err
+	}
+
+	padding := (4 - len(s)%4) % 4
+
+	_, err = w.Write(append([]byte(s), make([]byte, padding)...))
+	return err
 }
@@ -4,7 +4,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"unsafe"
 )
 
 // GenericInterfaceCounters is a generic switch counters record.
@@ -127,6 +126,30 @@ func (c VlanCounters) String() string {
 	return fmt.Sprintf("VlanCounters: %+v", x)
 }
 
+// InfiniBandCounters is an InfiniBand port counters record.
+type InfiniBandCounters struct {
+	TransmitPackets              uint32
+	ReceivePackets               uint32
+	SymbolErrors                 uint32
+	LinkErrorRecoveries          uint32
+	LinkDowned                   uint32
+	ReceiveErrors                uint32
+	ReceiveRemotePhysicalErrors  uint32
+	ReceiveSwitchRelayErrors     uint32
+	TransmitDiscards             uint32
+	TransmitConstraintErrors     uint32
+	ReceiveConstraintErrors      uint32
+	LocalLinkIntegrityErrors     uint32
+	ExcessiveBufferOverrunErrors uint32
+	VL15Dropped                  uint32
+}
+
+func (c InfiniBandCounters) String() string {
+	type X InfiniBandCounters
+	x := X(c)
+	return fmt.Sprintf("InfiniBandCounters: %+v", x)
+}
+
 // ProcessorCounters is a switch processor counters record.
 type ProcessorCounters struct {
 	CPU5s       uint32
@@ -234,32 +257,518 @@ func (c HostNetCounters) String() string {
 	return fmt.Sprintf("HostNetCounters: %+v", x)
 }
 
-var (
-	genericInterfaceCountersSize = uint32(unsafe.Sizeof(GenericInterfaceCounters{}))
-	ethernetCountersSize         = uint32(unsafe.Sizeof(EthernetCounters{}))
-	tokenRingCountersSize        = uint32(unsafe.Sizeof(TokenRingCounters{}))
-	vgCountersSize               = uint32(unsafe.Sizeof(VgCounters{}))
-	vlanCountersSize             = uint32(unsafe.Sizeof(VlanCounters{}))
-	processorCountersSize        = uint32(unsafe.Sizeof(ProcessorCounters{}))
-	hostCPUCountersSize          = uint32(unsafe.Sizeof(HostCPUCounters{}))
-	hostMemoryCountersSize       = uint32(unsafe.Sizeof(HostMemoryCounters{}))
-	hostDiskCountersSize         = uint32(unsafe.Sizeof(HostDiskCounters{}))
-	hostNetCountersSize          = uint32(unsafe.Sizeof(HostNetCounters{}))
-)
+// MIB2IPCounters is a MIB-2 IP group counters record.
+type MIB2IPCounters struct {
+	Forwarding         uint32
+	DefaultTTL         uint32
+	InReceives         uint32
+	InHeaderErrors     uint32
+	InAddressErrors    uint32
+	ForwardedDatagrams uint32
+	InUnknownProtocols uint32
+	InDiscards         uint32
+	InDelivers         uint32
+	OutRequests        uint32
+	OutDiscards        uint32
+	OutNoRoutes        uint32
+	ReassemblyTimeout  uint32
+	ReassemblyRequired uint32
+	ReassemblyOKs      uint32
+	ReassemblyFails    uint32
+	FragmentOKs        uint32
+	FragmentFails      uint32
+	FragmentCreates    uint32
+}
+
+func (c MIB2IPCounters) String() string {
+	type X MIB2IPCounters
+	x := X(c)
+	return fmt.Sprintf("MIB2IPCounters: %+v", x)
+}
+
+// MIB2ICMPCounters is a MIB-2 ICMP group counters record.
+type MIB2ICMPCounters struct {
+	InMessages                 uint32
+	InErrors                   uint32
+	InDestinationUnreachables  uint32
+	InTimeExceeded             uint32
+	InParameterProblems        uint32
+	InSourceQuenches           uint32
+	InRedirects                uint32
+	InEchos                    uint32
+	InEchoReplies              uint32
+	InTimestamps               uint32
+	InAddressMasks             uint32
+	InAddressMaskReplies       uint32
+	OutMessages                uint32
+	OutErrors                  uint32
+	OutDestinationUnreachables uint32
+	OutTimeExceeded            uint32
+	OutParameterProblems       uint32
+	OutSourceQuenches          uint32
+	OutRedirects               uint32
+	OutEchos                   uint32
+	OutEchoReplies             uint32
+	OutTimestamps              uint32
+	OutTimestampReplies        uint32
+	OutAddressMasks            uint32
+	OutAddressMaskReplies      uint32
+}
+
+func (c MIB2ICMPCounters) String() string {
+	type X MIB2ICMPCounters
+	x := X(c)
+	return fmt.Sprintf("MIB2ICMPCounters: %+v", x)
+}
+
+// MIB2TCPCounters is a MIB-2 TCP group counters record.
+type MIB2TCPCounters struct {
+	RtoAlgorithm          uint32
+	RtoMin                uint32
+	RtoMax                uint32
+	MaxConnections        uint32
+	ActiveOpens           uint32
+	PassiveOpens          uint32
+	AttemptFails          uint32
+	EstablishedResets     uint32
+	CurrentEstablished    uint32
+	InSegments            uint32
+	OutSegments           uint32
+	RetransmittedSegments uint32
+	InErrors              uint32
+	OutResets             uint32
+	InChecksumErrors      uint32
+}
+
+func (c MIB2TCPCounters) String() string {
+	type X MIB2TCPCounters
+	x := X(c)
+	return fmt.Sprintf("MIB2TCPCounters: %+v", x)
+}
+
+// MIB2UDPCounters is a MIB-2 UDP group counters record.
+type MIB2UDPCounters struct {
+	InDatagrams         uint32
+	NoPorts             uint32
+	InErrors            uint32
+	OutDatagrams        uint32
+	ReceiveBufferErrors uint32
+	SendBufferErrors    uint32
+	InChecksumErrors    uint32
+}
+
+func (c MIB2UDPCounters) String() string {
+	type X MIB2UDPCounters
+	x := X(c)
+	return fmt.Sprintf("MIB2UDPCounters: %+v", x)
+}
+
+// VirtNodeCounters is a virtual node (hypervisor) counters record.
+type VirtNodeCounters struct {
+	MHz        uint32
+	CPUs       uint32
+	Memory     uint64
+	MemoryFree uint64
+	NumDomains uint32
+}
+
+func (c VirtNodeCounters) String() string {
+	type X VirtNodeCounters
+	x := X(c)
+	return fmt.Sprintf("VirtNodeCounters: %+v", x)
+}
+
+// VirtCPUCounters is a virtual domain CPU counters record.
+type VirtCPUCounters struct {
+	State      uint32
+	CPUTime    uint32
+	NumVirtCPU uint32
+}
+
+func (c VirtCPUCounters) String() string {
+	type X VirtCPUCounters
+	x := X(c)
+	return fmt.Sprintf("VirtCPUCounters: %+v", x)
+}
+
+// VirtMemoryCounters is a virtual domain memory counters record.
+type VirtMemoryCounters struct {
+	Memory    uint64
+	MaxMemory uint64
+}
+
+func (c VirtMemoryCounters) String() string {
+	type X VirtMemoryCounters
+	x := X(c)
+	return fmt.Sprintf("VirtMemoryCounters: %+v", x)
+}
+
+// VirtDiskIOCounters is a virtual domain disk counters record.
+type VirtDiskIOCounters struct {
+	Capacity      uint64
+	Allocation    uint64
+	Available     uint64
+	ReadRequests  uint32
+	BytesRead     uint64
+	WriteRequests uint32
+	BytesWritten  uint64
+	Errors        uint32
+}
+
+func (c VirtDiskIOCounters) String() string {
+	type X VirtDiskIOCounters
+	x := X(c)
+	return fmt.Sprintf("VirtDiskIOCounters: %+v", x)
+}
+
+// VirtNetIOCounters is a virtual domain network counters record.
+type VirtNetIOCounters struct {
+	BytesIn   uint64
+	PacketsIn uint32
+	ErrorsIn  uint32
+	DropsIn   uint32
+
+	BytesOut   uint64
+	PacketsOut uint32
+	ErrorsOut  uint32
+	DropsOut   uint32
+}
+
+func (c VirtNetIOCounters) String() string {
+	type X VirtNetIOCounters
+	x := X(c)
+	return fmt.Sprintf("VirtNetIOCounters: %+v", x)
+}
+
+// JVMRuntime is a Java virtual machine runtime record.
+type JVMRuntime struct {
+	Name    string
+	Vendor  string
+	Version string
+}
+
+func (c JVMRuntime) String() string {
+	type X JVMRuntime
+	x := X(c)
+	return fmt.Sprintf("JVMRuntime: %+v", x)
+}
+
+// JVMStatistics is a Java virtual machine statistics counters record.
+type JVMStatistics struct {
+	HeapInitial      uint64
+	HeapUsed         uint64
+	HeapCommitted    uint64
+	HeapMax          uint64
+	NonHeapInitial   uint64
+	NonHeapUsed      uint64
+	NonHeapCommitted uint64
+	NonHeapMax       uint64
+	GCCount          uint32
+	GCTime           uint32
+	ClassesLoaded    uint32
+	ClassesTotal     uint32
+	ClassesUnloaded  uint32
+	CompilationTime  uint32
+	ThreadsLive      uint32
+	ThreadsDaemon    uint32
+	ThreadsStarted   uint32
+	FDOpen           uint32
+	FDMax            uint32
+}
+
+func (c JVMStatistics) String() string {
+	type X JVMStatistics
+	x := X(c)
+	return fmt.Sprintf("JVMStatistics: %+v", x)
+}
+
+// AppResources is an application resource usage counters record.
+type AppResources struct {
+	UserTime        uint32
+	SystemTime      uint32
+	MemoryUsed      uint64
+	MemoryMax       uint64
+	FDOpen          uint32
+	FDMax           uint32
+	ConnectionsOpen uint32
+	ConnectionsMax  uint32
+}
+
+func (c AppResources) String() string {
+	type X AppResources
+	x := X(c)
+	return fmt.Sprintf("AppResources: %+v", x)
+}
+
+// AppWorkers is an application worker pool counters record.
+type AppWorkers struct {
+	WorkersActive   uint32
+	WorkersIdle     uint32
+	WorkersMax      uint32
+	RequestsDelayed uint32
+	RequestsDropped uint32
+}
+
+func (c AppWorkers) String() string {
+	type X AppWorkers
+	x := X(c)
+	return fmt.Sprintf("AppWorkers: %+v", x)
+}
+
+// AppOperations is an application operations counters record: the name
+// of the application followed by counts of operations by status.
+type AppOperations struct {
+	Application    string
+	Success        uint32
+	Other          uint32
+	Timeout        uint32
+	InternalError  uint32
+	BadRequest     uint32
+	Forbidden      uint32
+	TooLarge       uint32
+	NotImplemented uint32
+	NotFound       uint32
+	Unavailable    uint32
+	Unauthorized   uint32
+}
+
+func (c AppOperations) String() string {
+	type X AppOperations
+	x := X(c)
+	return fmt.Sprintf("AppOperations: %+v", x)
+}
+
+// ApplicationCounters is an application counters record. It has
+// the layout of AppOperations.
+type ApplicationCounters AppOperations
+
+func (c ApplicationCounters) String() string {
+	type X ApplicationCounters
+	x := X(c)
+	return fmt.Sprintf("ApplicationCounters: %+v", x)
+}
+
+// EnergyCounters is a power supply energy counters record.
+type EnergyCounters struct {
+	Voltage     uint32
+	Current     uint32
+	RealPower   uint32
+	PowerFactor int32
+	Energy      uint32
+	Errors      uint32
+}
+
+func (c EnergyCounters) String() string {
+	type X EnergyCounters
+	x := X(c)
+	return fmt.Sprintf("EnergyCounters: %+v", x)
+}
+
+// TemperatureCounters is a temperature sensor counters record.
+type TemperatureCounters struct {
+	Minimum int32
+	Maximum int32
+	Errors  uint32
+}
+
+func (c TemperatureCounters) String() string {
+	type X TemperatureCounters
+	x := X(c)
+	return fmt.Sprintf("TemperatureCounters: %+v", x)
+}
+
+// HumidityCounters is a humidity sensor counters record.
+type HumidityCounters struct {
+	Relative int32
+}
+
+func (c HumidityCounters) String() string {
+	type X HumidityCounters
+	x := X(c)
+	return fmt.Sprintf("HumidityCounters: %+v", x)
+}
+
+// FanCounters is a cooling fan counters record.
+type FanCounters struct {
+	Total  uint32
+	Failed uint32
+	Speed  uint32
+}
+
+func (c FanCounters) String() string {
+	type X FanCounters
+	x := X(c)
+	return fmt.Sprintf("FanCounters: %+v", x)
+}
+
+// BroadcomDeviceBuffers is a Broadcom switch ASIC device buffer utilization
+// counters record. Utilizations are in hundredths of a percent.
+type BroadcomDeviceBuffers struct {
+	UnicastPercent   int32
+	MulticastPercent int32
+}
+
+func (c BroadcomDeviceBuffers) String() string {
+	type X BroadcomDeviceBuffers
+	x := X(c)
+	return fmt.Sprintf("BroadcomDeviceBuffers: %+v", x)
+}
+
+// BroadcomPortBuffers is a Broadcom switch ASIC port buffer utilization
+// counters record. Utilizations are in hundredths of a percent.
+type BroadcomPortBuffers struct {
+	IngressUnicastPercent       int32
+	IngressMulticastPercent     int32
+	EgressUnicastPercent        int32
+	EgressMulticastPercent      int32
+	EgressQueueUnicastPercent   []int32
+	EgressQueueMulticastPercent []int32
+}
+
+func (c BroadcomPortBuffers) String() string {
+	type X BroadcomPortBuffers
+	x := X(c)
+	return fmt.Sprintf("BroadcomPortBuffers: %+v", x)
+}
+
+// BroadcomTables is a Broadcom switch ASIC hardware table utilization
+// counters record.
+type BroadcomTables struct {
+	HostEntries           uint32
+	HostEntriesMax        uint32
+	IPv4Entries           uint32
+	IPv4EntriesMax        uint32
+	IPv6Entries           uint32
+	IPv6EntriesMax        uint32
+	IPv4IPv6Entries       uint32
+	IPv4IPv6EntriesMax    uint32
+	LongIPv6Entries       uint32
+	LongIPv6EntriesMax    uint32
+	TotalRoutes           uint32
+	TotalRoutesMax        uint32
+	ECMPNextHops          uint32
+	ECMPNextHopsMax       uint32
+	MACEntries            uint32
+	MACEntriesMax         uint32
+	IPv4Neighbors         uint32
+	IPv6Neighbors         uint32
+	IPv4Routes            uint32
+	IPv6Routes            uint32
+	ACLIngressEntries     uint32
+	ACLIngressEntriesMax  uint32
+	ACLIngressCounters    uint32
+	ACLIngressCountersMax uint32
+	ACLIngressMeters      uint32
+	ACLIngressMetersMax   uint32
+	ACLIngressSlices      uint32
+	ACLIngressSlicesMax   uint32
+	ACLEgressEntries      uint32
+	ACLEgressEntriesMax   uint32
+	ACLEgressCounters     uint32
+	ACLEgressCountersMax  uint32
+	ACLEgressMeters       uint32
+	ACLEgressMetersMax    uint32
+	ACLEgressSlices       uint32
+	ACLEgressSlicesMax    uint32
+}
+
+func (c BroadcomTables) String() string {
+	type X BroadcomTables
+	x := X(c)
+	return fmt.Sprintf("BroadcomTables: %+v", x)
+}
+
+// NVMLGPUCounters is an NVIDIA GPU counters record, as reported by NVML.
+// Counters are summed across devices, except Temperature and FanSpeed,
+// which are the maximum across devices.
+type NVMLGPUCounters struct {
+	DeviceCount uint32
+	Processes   uint32
+	GPUTime     uint32
+	MemoryTime  uint32
+	MemoryTotal uint64
+	MemoryFree  uint64
+	ECCErrors   uint32
+	Energy      uint32
+	Temperature uint32
+	FanSpeed    uint32
+}
+
+func (c NVMLGPUCounters) String() string {
+	type X NVMLGPUCounters
+	x := X(c)
+	return fmt.Sprintf("NVMLGPUCounters: %+v", x)
+}
+
+// HTTPCounters is an HTTP request method and status counters record.
+type HTTPCounters struct {
+	MethodOptionCount  uint32
+	MethodGetCount     uint32
+	MethodHeadCount    uint32
+	MethodPostCount    uint32
+	MethodPutCount     uint32
+	MethodDeleteCount  uint32
+	MethodTraceCount   uint32
+	MethodConnectCount uint32
+	MethodOtherCount   uint32
+	Status1XXCount     uint32
+	Status2XXCount     uint32
+	Status3XXCount     uint32
+	Status4XXCount     uint32
+	Status5XXCount     uint32
+	StatusOtherCount   uint32
+}
+
+func (c HTTPCounters) String() string {
+	type X HTTPCounters
+	x := X(c)
+	return fmt.Sprintf("HTTPCounters: %+v", x)
+}
+
+func init() {
+	registerRecord(counterRecordFormats, TypeGenericInterfaceCountersRecord, decodeGenericInterfaceCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeEthernetCountersRecord, decodeEthernetCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeTokenRingCountersRecord, decodeTokenRingCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeVgCountersRecord, decodeVgCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeVlanCountersRecord, decodeVlanCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeInfiniBandCountersRecord, decodeInfiniBandCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeProcessorCountersRecord, decodeProcessorCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeHostCPUCountersRecord, decodeHostCPUCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeHostMemoryCountersRecord, decodeHostMemoryCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeHostDiskCountersRecord, decodeHostDiskCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeHostNetCountersRecord, decodeHostNetCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeMIB2IPCountersRecord, decodeMIB2IPCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeMIB2ICMPCountersRecord, decodeMIB2ICMPCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeMIB2TCPCountersRecord, decodeMIB2TCPCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeMIB2UDPCountersRecord, decodeMIB2UDPCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeVirtNodeCountersRecord, decodeVirtNodeCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeVirtCPUCountersRecord, decodeVirtCPUCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeVirtMemoryCountersRecord, decodeVirtMemoryCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeVirtDiskIOCountersRecord, decodeVirtDiskIOCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeVirtNetIOCountersRecord, decodeVirtNetIOCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeJVMRuntimeRecord, decodeJVMRuntimeRecord, encodeJVMRuntimeRecord)
+	registerRecord(counterRecordFormats, TypeJVMStatisticsRecord, decodeJVMStatisticsRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeAppResourcesRecord, decodeAppResourcesRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeAppWorkersRecord, decodeAppWorkersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeHTTPCountersRecord, decodeHTTPCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeAppOperationsRecord, decodeAppOperationsRecord, encodeAppOperationsRecord)
+	registerRecord(counterRecordFormats, TypeApplicationCountersRecord, decodeApplicationCountersRecord, encodeApplicationCountersRecord)
+	registerRecord(counterRecordFormats, TypeEnergyCountersRecord, decodeEnergyCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeTemperatureCountersRecord, decodeTemperatureCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeHumidityCountersRecord, decodeHumidityCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeFanCountersRecord, decodeFanCountersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeBroadcomDeviceBuffersRecord, decodeBroadcomDeviceBuffersRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeBroadcomPortBuffersRecord, decodeBroadcomPortBuffersRecord, encodeBroadcomPortBuffersRecord)
+	registerRecord(counterRecordFormats, TypeBroadcomTablesRecord, decodeBroadcomTablesRecord, encodeFixedRecord)
+	registerRecord(counterRecordFormats, TypeNVMLGPUCountersRecord, decodeNVMLGPUCountersRecord, encodeFixedRecord)
+}
 
 // RecordType returns the type of counter record.
-func (c GenericInterfaceCounters) RecordType() int {
+func (c GenericInterfaceCounters) RecordType() DataFormat {
 	return TypeGenericInterfaceCountersRecord
 }
 
-func decodeGenericInterfaceCountersRecord(r io.Reader, length uint32) (GenericInterfaceCounters, error) {
-	c := GenericInterfaceCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeGenericInterfaceCountersRecord(b []byte, c *GenericInterfaceCounters) (int, error) {
 	fields := []interface{}{
 		&c.Index,
 		&c.Type,
@@ -282,39 +791,15 @@ func decodeGenericInterfaceCountersRecord(r io.Reader, length uint32) (GenericIn
 		&c.PromiscuousMode,
 	}
 
-	return c, readFields(b, fields)
-}
-
-func (c GenericInterfaceCounters) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, genericInterfaceCountersSize)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return readFields(b, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c EthernetCounters) RecordType() int {
+func (c EthernetCounters) RecordType() DataFormat {
 	return TypeEthernetCountersRecord
 }
 
-func decodeEthernetCountersRecord(r io.Reader, length uint32) (EthernetCounters, error) {
-	c := EthernetCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeEthernetCountersRecord(b []byte, c *EthernetCounters) (int, error) {
 	fields := []interface{}{
 		&c.AlignmentErrors,
 		&c.FCSErrors,
@@ -331,39 +816,15 @@ func decodeEthernetCountersRecord(r io.Reader, length uint32) (EthernetCounters,
 		&c.SymbolErrors,
 	}
 
-	return c, readFields(b, fields)
-}
-
-func (c EthernetCounters) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, ethernetCountersSize)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return readFields(b, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c TokenRingCounters) RecordType() int {
+func (c TokenRingCounters) RecordType() DataFormat {
 	return TypeTokenRingCountersRecord
 }
 
-func decodeTokenRingCountersRecord(r io.Reader, length uint32) (TokenRingCounters, error) {
-	c := TokenRingCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeTokenRingCountersRecord(b []byte, c *TokenRingCounters) (int, error) {
 	fields := []interface{}{
 		&c.LineErrors,
 		&c.BurstErrors,
@@ -385,39 +846,15 @@ func decodeTokenRingCountersRecord(r io.Reader, length uint32) (TokenRingCounter
 		&c.FreqErrors,
 	}
 
-	return c, readFields(b, fields)
-}
-
-func (c TokenRingCounters) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, tokenRingCountersSize)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return readFields(b, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c VgCounters) RecordType() int {
+func (c VgCounters) RecordType() DataFormat {
 	return TypeVgCountersRecord
 }
 
-func decodeVgCountersRecord(r io.Reader, length uint32) (VgCounters, error) {
-	c := VgCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeVgCountersRecord(b []byte, c *VgCounters) (int, error) {
 	fields := []interface{}{
 		&c.InHighPriorityFrames,
 		&c.InHighPriorityOctets,
@@ -435,39 +872,15 @@ func decodeVgCountersRecord(r io.Reader, length uint32) (VgCounters, error) {
 		&c.HCOutHighPriorityOctets,
 	}
 
-	return c, readFields(b, fields)
-}
-
-func (c VgCounters) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, vgCountersSize)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return readFields(b, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c VlanCounters) RecordType() int {
+func (c VlanCounters) RecordType() DataFormat {
 	return TypeVlanCountersRecord
 }
 
-func decodeVlanCountersRecord(r io.Reader, length uint32) (VlanCounters, error) {
-	c := VlanCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeVlanCountersRecord(b []byte, c *VlanCounters) (int, error) {
 	fields := []interface{}{
 		&c.ID,
 		&c.Octets,
@@ -477,39 +890,41 @@ func decodeVlanCountersRecord(r io.Reader, length uint32) (VlanCounters, error)
 		&c.Discards,
 	}
 
-	return c, readFields(b, fields)
+	return readFields(b, fields)
 }
 
-func (c VlanCounters) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
-	}
+// RecordType returns the type of counter record.
+func (c InfiniBandCounters) RecordType() DataFormat {
+	return TypeInfiniBandCountersRecord
+}
 
-	err = binary.Write(w, binary.BigEndian, vlanCountersSize)
-	if err != nil {
-		return err
+func decodeInfiniBandCountersRecord(b []byte, c *InfiniBandCounters) (int, error) {
+	fields := []interface{}{
+		&c.TransmitPackets,
+		&c.ReceivePackets,
+		&c.SymbolErrors,
+		&c.LinkErrorRecoveries,
+		&c.LinkDowned,
+		&c.ReceiveErrors,
+		&c.ReceiveRemotePhysicalErrors,
+		&c.ReceiveSwitchRelayErrors,
+		&c.TransmitDiscards,
+		&c.TransmitConstraintErrors,
+		&c.ReceiveConstraintErrors,
+		&c.LocalLinkIntegrityErrors,
+		&c.ExcessiveBufferOverrunErrors,
+		&c.VL15Dropped,
 	}
 
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return readFields(b, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c ProcessorCounters) RecordType() int {
+func (c ProcessorCounters) RecordType() DataFormat {
 	return TypeProcessorCountersRecord
 }
 
-func decodeProcessorCountersRecord(r io.Reader, length uint32) (ProcessorCounters, error) {
-	c := ProcessorCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeProcessorCountersRecord(b []byte, c *ProcessorCounters) (int, error) {
 	fields := []interface{}{
 		&c.CPU5s,
 		&c.CPU1m,
@@ -518,39 +933,15 @@ func decodeProcessorCountersRecord(r io.Reader, length uint32) (ProcessorCounter
 		&c.FreeMemory,
 	}
 
-	return c, readFields(b, fields)
-}
-
-func (c ProcessorCounters) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, processorCountersSize)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return readFields(b, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c HostCPUCounters) RecordType() int {
+func (c HostCPUCounters) RecordType() DataFormat {
 	return TypeHostCPUCountersRecord
 }
 
-func decodeHostCPUCountersRecord(r io.Reader, length uint32) (HostCPUCounters, error) {
-	c := HostCPUCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeHostCPUCountersRecord(b []byte, c *HostCPUCounters) (int, error) {
 	fields := []interface{}{
 		&c.Load1m,
 		&c.Load5m,
@@ -569,44 +960,31 @@ func decodeHostCPUCountersRecord(r io.Reader, length uint32) (HostCPUCounters, e
 		&c.CPUSoftIntr,
 		&c.Interrupts,
 		&c.ContextSwitches,
-		&c.CPUSteal,
-		&c.CPUGuest,
-		&c.CPUGuestNice,
 	}
 
-	return c, readFields(b, fields)
-}
-
-func (c HostCPUCounters) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
+	n, err := readFields(b, fields)
+	if err != nil || len(b) == n {
+		return n, err
 	}
 
-	err = binary.Write(w, binary.BigEndian, hostCPUCountersSize)
-	if err != nil {
-		return err
+	// The steal and guest times were added to the record later,
+	// so agents may not send them.
+	fields = []interface{}{
+		&c.CPUSteal,
+		&c.CPUGuest,
+		&c.CPUGuestNice,
 	}
 
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	m, err := readFields(b[n:], fields)
+	return n + m, err
 }
 
 // RecordType returns the type of counter record.
-func (c HostMemoryCounters) RecordType() int {
+func (c HostMemoryCounters) RecordType() DataFormat {
 	return TypeHostMemoryCountersRecord
 }
 
-func decodeHostMemoryCountersRecord(r io.Reader, length uint32) (HostMemoryCounters, error) {
-	c := HostMemoryCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeHostMemoryCountersRecord(b []byte, c *HostMemoryCounters) (int, error) {
 	fields := []interface{}{
 		&c.Total,
 		&c.Free,
@@ -621,39 +999,15 @@ func decodeHostMemoryCountersRecord(r io.Reader, length uint32) (HostMemoryCount
 		&c.SwapOut,
 	}
 
-	return c, readFields(b, fields)
-}
-
-func (c HostMemoryCounters) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, hostMemoryCountersSize)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return readFields(b, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c HostDiskCounters) RecordType() int {
+func (c HostDiskCounters) RecordType() DataFormat {
 	return TypeHostDiskCountersRecord
 }
 
-func decodeHostDiskCountersRecord(r io.Reader, length uint32) (HostDiskCounters, error) {
-	c := HostDiskCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
-	}
-
+func decodeHostDiskCountersRecord(b []byte, c *HostDiskCounters) (int, error) {
 	fields := []interface{}{
 		&c.Total,
 		&c.Free,
@@ -666,39 +1020,215 @@ func decodeHostDiskCountersRecord(r io.Reader, length uint32) (HostDiskCounters,
 		&c.WriteTime,
 	}
 
-	return c, readFields(b, fields)
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c HostNetCounters) RecordType() DataFormat {
+	return TypeHostNetCountersRecord
+}
+
+func decodeHostNetCountersRecord(b []byte, c *HostNetCounters) (int, error) {
+	fields := []interface{}{
+		&c.BytesIn,
+		&c.PacketsIn,
+		&c.ErrorsIn,
+		&c.DropsIn,
+		&c.BytesOut,
+		&c.PacketsOut,
+		&c.ErrorsOut,
+		&c.DropsOut,
+	}
+
+	return readFields(b, fields)
 }
 
-func (c HostDiskCounters) encode(w io.Writer) error {
-	var err error
+// RecordType returns the type of counter record.
+func (c MIB2IPCounters) RecordType() DataFormat {
+	return TypeMIB2IPCountersRecord
+}
 
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
-	if err != nil {
-		return err
+func decodeMIB2IPCountersRecord(b []byte, c *MIB2IPCounters) (int, error) {
+	fields := []interface{}{
+		&c.Forwarding,
+		&c.DefaultTTL,
+		&c.InReceives,
+		&c.InHeaderErrors,
+		&c.InAddressErrors,
+		&c.ForwardedDatagrams,
+		&c.InUnknownProtocols,
+		&c.InDiscards,
+		&c.InDelivers,
+		&c.OutRequests,
+		&c.OutDiscards,
+		&c.OutNoRoutes,
+		&c.ReassemblyTimeout,
+		&c.ReassemblyRequired,
+		&c.ReassemblyOKs,
+		&c.ReassemblyFails,
+		&c.FragmentOKs,
+		&c.FragmentFails,
+		&c.FragmentCreates,
 	}
 
-	err = binary.Write(w, binary.BigEndian, hostDiskCountersSize)
-	if err != nil {
-		return err
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c MIB2ICMPCounters) RecordType() DataFormat {
+	return TypeMIB2ICMPCountersRecord
+}
+
+func decodeMIB2ICMPCountersRecord(b []byte, c *MIB2ICMPCounters) (int, error) {
+	fields := []interface{}{
+		&c.InMessages,
+		&c.InErrors,
+		&c.InDestinationUnreachables,
+		&c.InTimeExceeded,
+		&c.InParameterProblems,
+		&c.InSourceQuenches,
+		&c.InRedirects,
+		&c.InEchos,
+		&c.InEchoReplies,
+		&c.InTimestamps,
+		&c.InAddressMasks,
+		&c.InAddressMaskReplies,
+		&c.OutMessages,
+		&c.OutErrors,
+		&c.OutDestinationUnreachables,
+		&c.OutTimeExceeded,
+		&c.OutParameterProblems,
+		&c.OutSourceQuenches,
+		&c.OutRedirects,
+		&c.OutEchos,
+		&c.OutEchoReplies,
+		&c.OutTimestamps,
+		&c.OutTimestampReplies,
+		&c.OutAddressMasks,
+		&c.OutAddressMaskReplies,
 	}
 
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return readFields(b, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c HostNetCounters) RecordType() int {
-	return TypeHostNetCountersRecord
+func (c MIB2TCPCounters) RecordType() DataFormat {
+	return TypeMIB2TCPCountersRecord
+}
+
+func decodeMIB2TCPCountersRecord(b []byte, c *MIB2TCPCounters) (int, error) {
+	fields := []interface{}{
+		&c.RtoAlgorithm,
+		&c.RtoMin,
+		&c.RtoMax,
+		&c.MaxConnections,
+		&c.ActiveOpens,
+		&c.PassiveOpens,
+		&c.AttemptFails,
+		&c.EstablishedResets,
+		&c.CurrentEstablished,
+		&c.InSegments,
+		&c.OutSegments,
+		&c.RetransmittedSegments,
+		&c.InErrors,
+		&c.OutResets,
+		&c.InChecksumErrors,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c MIB2UDPCounters) RecordType() DataFormat {
+	return TypeMIB2UDPCountersRecord
+}
+
+func decodeMIB2UDPCountersRecord(b []byte, c *MIB2UDPCounters) (int, error) {
+	fields := []interface{}{
+		&c.InDatagrams,
+		&c.NoPorts,
+		&c.InErrors,
+		&c.OutDatagrams,
+		&c.ReceiveBufferErrors,
+		&c.SendBufferErrors,
+		&c.InChecksumErrors,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c VirtNodeCounters) RecordType() DataFormat {
+	return TypeVirtNodeCountersRecord
+}
+
+func decodeVirtNodeCountersRecord(b []byte, c *VirtNodeCounters) (int, error) {
+	fields := []interface{}{
+		&c.MHz,
+		&c.CPUs,
+		&c.Memory,
+		&c.MemoryFree,
+		&c.NumDomains,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c VirtCPUCounters) RecordType() DataFormat {
+	return TypeVirtCPUCountersRecord
 }
 
-func decodeHostNetCountersRecord(r io.Reader, length uint32) (HostNetCounters, error) {
-	c := HostNetCounters{}
-	b := make([]byte, int(length))
-	n, _ := r.Read(b)
-	if n != int(length) {
-		return c, ErrDecodingRecord
+func decodeVirtCPUCountersRecord(b []byte, c *VirtCPUCounters) (int, error) {
+	fields := []interface{}{
+		&c.State,
+		&c.CPUTime,
+		&c.NumVirtCPU,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c VirtMemoryCounters) RecordType() DataFormat {
+	return TypeVirtMemoryCountersRecord
+}
+
+func decodeVirtMemoryCountersRecord(b []byte, c *VirtMemoryCounters) (int, error) {
+	fields := []interface{}{
+		&c.Memory,
+		&c.MaxMemory,
 	}
 
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c VirtDiskIOCounters) RecordType() DataFormat {
+	return TypeVirtDiskIOCountersRecord
+}
+
+func decodeVirtDiskIOCountersRecord(b []byte, c *VirtDiskIOCounters) (int, error) {
+	fields := []interface{}{
+		&c.Capacity,
+		&c.Allocation,
+		&c.Available,
+		&c.ReadRequests,
+		&c.BytesRead,
+		&c.WriteRequests,
+		&c.BytesWritten,
+		&c.Errors,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c VirtNetIOCounters) RecordType() DataFormat {
+	return TypeVirtNetIOCountersRecord
+}
+
+func decodeVirtNetIOCountersRecord(b []byte, c *VirtNetIOCounters) (int, error) {
 	fields := []interface{}{
 		&c.BytesIn,
 		&c.PacketsIn,
@@ -710,22 +1240,448 @@ func decodeHostNetCountersRecord(r io.Reader, length uint32) (HostNetCounters, e
 		&c.DropsOut,
 	}
 
-	return c, readFields(b, fields)
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c JVMRuntime) RecordType() DataFormat {
+	return TypeJVMRuntimeRecord
+}
+
+func decodeJVMRuntimeRecord(b []byte, c *JVMRuntime) (int, error) {
+	strings := []*string{
+		&c.Name,
+		&c.Vendor,
+		&c.Version,
+	}
+
+	size := len(b)
+
+	for _, str := range strings {
+		n, err := readString(b, str)
+		if err != nil {
+			return 0, err
+		}
+
+		b = b[n:]
+	}
+
+	return size - len(b), nil
+}
+
+func encodeJVMRuntimeRecord(w io.Writer, rec Record) error {
+	c, ok := rec.(JVMRuntime)
+	if !ok {
+		return ErrEncodingRecord
+	}
+
+	for _, str := range []string{c.Name, c.Vendor, c.Version} {
+		err := writeString(w, str)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordType returns the type of counter record.
+func (c JVMStatistics) RecordType() DataFormat {
+	return TypeJVMStatisticsRecord
+}
+
+func decodeJVMStatisticsRecord(b []byte, c *JVMStatistics) (int, error) {
+	fields := []interface{}{
+		&c.HeapInitial,
+		&c.HeapUsed,
+		&c.HeapCommitted,
+		&c.HeapMax,
+		&c.NonHeapInitial,
+		&c.NonHeapUsed,
+		&c.NonHeapCommitted,
+		&c.NonHeapMax,
+		&c.GCCount,
+		&c.GCTime,
+		&c.ClassesLoaded,
+		&c.ClassesTotal,
+		&c.ClassesUnloaded,
+		&c.CompilationTime,
+		&c.ThreadsLive,
+		&c.ThreadsDaemon,
+		&c.ThreadsStarted,
+		&c.FDOpen,
+		&c.FDMax,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c AppResources) RecordType() DataFormat {
+	return TypeAppResourcesRecord
+}
+
+func decodeAppResourcesRecord(b []byte, c *AppResources) (int, error) {
+	fields := []interface{}{
+		&c.UserTime,
+		&c.SystemTime,
+		&c.MemoryUsed,
+		&c.MemoryMax,
+		&c.FDOpen,
+		&c.FDMax,
+		&c.ConnectionsOpen,
+		&c.ConnectionsMax,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c AppWorkers) RecordType() DataFormat {
+	return TypeAppWorkersRecord
+}
+
+func decodeAppWorkersRecord(b []byte, c *AppWorkers) (int, error) {
+	fields := []interface{}{
+		&c.WorkersActive,
+		&c.WorkersIdle,
+		&c.WorkersMax,
+		&c.RequestsDelayed,
+		&c.RequestsDropped,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c HTTPCounters) RecordType() DataFormat {
+	return TypeHTTPCountersRecord
+}
+
+func decodeHTTPCountersRecord(b []byte, c *HTTPCounters) (int, error) {
+	fields := []interface{}{
+		&c.MethodOptionCount,
+		&c.MethodGetCount,
+		&c.MethodHeadCount,
+		&c.MethodPostCount,
+		&c.MethodPutCount,
+		&c.MethodDeleteCount,
+		&c.MethodTraceCount,
+		&c.MethodConnectCount,
+		&c.MethodOtherCount,
+		&c.Status1XXCount,
+		&c.Status2XXCount,
+		&c.Status3XXCount,
+		&c.Status4XXCount,
+		&c.Status5XXCount,
+		&c.StatusOtherCount,
+	}
+
+	return readFields(b, fields)
 }
 
-func (c HostNetCounters) encode(w io.Writer) error {
-	var err error
+// RecordType returns the type of counter record.
+func (c AppOperations) RecordType() DataFormat {
+	return TypeAppOperationsRecord
+}
 
-	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+func decodeAppOperationsRecord(b []byte, c *AppOperations) (int, error) {
+	n, err := readString(b, &c.Application)
+	if err != nil {
+		return 0, err
+	}
+
+	fields := []interface{}{
+		&c.Success,
+		&c.Other,
+		&c.Timeout,
+		&c.InternalError,
+		&c.BadRequest,
+		&c.Forbidden,
+		&c.TooLarge,
+		&c.NotImplemented,
+		&c.NotFound,
+		&c.Unavailable,
+		&c.Unauthorized,
+	}
+
+	m, err := readFields(b[n:], fields)
+	return n + m, err
+}
+
+func encodeAppOperationsRecord(w io.Writer, rec Record) error {
+	c, ok := rec.(AppOperations)
+	if !ok {
+		return ErrEncodingRecord
+	}
+
+	return writeAppOperations(w, c)
+}
+
+func writeAppOperations(w io.Writer, c AppOperations) error {
+	err := writeString(w, c.Application)
 	if err != nil {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, hostNetCountersSize)
+	return binary.Write(w, binary.BigEndian, []uint32{
+		c.Success,
+		c.Other,
+		c.Timeout,
+		c.InternalError,
+		c.BadRequest,
+		c.Forbidden,
+		c.TooLarge,
+		c.NotImplemented,
+		c.NotFound,
+		c.Unavailable,
+		c.Unauthorized,
+	})
+}
+
+// RecordType returns the type of counter record.
+func (c ApplicationCounters) RecordType() DataFormat {
+	return TypeApplicationCountersRecord
+}
+
+func decodeApplicationCountersRecord(b []byte, c *ApplicationCounters) (int, error) {
+	return decodeAppOperationsRecord(b, (*AppOperations)(c))
+}
+
+func encodeApplicationCountersRecord(w io.Writer, rec Record) error {
+	c, ok := rec.(ApplicationCounters)
+	if !ok {
+		return ErrEncodingRecord
+	}
+
+	return writeAppOperations(w, AppOperations(c))
+}
+
+// RecordType returns the type of counter record.
+func (c EnergyCounters) RecordType() DataFormat {
+	return TypeEnergyCountersRecord
+}
+
+func decodeEnergyCountersRecord(b []byte, c *EnergyCounters) (int, error) {
+	fields := []interface{}{
+		&c.Voltage,
+		&c.Current,
+		&c.RealPower,
+		&c.PowerFactor,
+		&c.Energy,
+		&c.Errors,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c TemperatureCounters) RecordType() DataFormat {
+	return TypeTemperatureCountersRecord
+}
+
+func decodeTemperatureCountersRecord(b []byte, c *TemperatureCounters) (int, error) {
+	fields := []interface{}{
+		&c.Minimum,
+		&c.Maximum,
+		&c.Errors,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c HumidityCounters) RecordType() DataFormat {
+	return TypeHumidityCountersRecord
+}
+
+func decodeHumidityCountersRecord(b []byte, c *HumidityCounters) (int, error) {
+	fields := []interface{}{
+		&c.Relative,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c FanCounters) RecordType() DataFormat {
+	return TypeFanCountersRecord
+}
+
+func decodeFanCountersRecord(b []byte, c *FanCounters) (int, error) {
+	fields := []interface{}{
+		&c.Total,
+		&c.Failed,
+		&c.Speed,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c BroadcomDeviceBuffers) RecordType() DataFormat {
+	return TypeBroadcomDeviceBuffersRecord
+}
+
+func decodeBroadcomDeviceBuffersRecord(b []byte, c *BroadcomDeviceBuffers) (int, error) {
+	fields := []interface{}{
+		&c.UnicastPercent,
+		&c.MulticastPercent,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c BroadcomPortBuffers) RecordType() DataFormat {
+	return TypeBroadcomPortBuffersRecord
+}
+
+func decodeBroadcomPortBuffersRecord(b []byte, c *BroadcomPortBuffers) (int, error) {
+	size := len(b)
+
+	if len(b) < 4*4 {
+		return 0, ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.IngressUnicastPercent,
+		&c.IngressMulticastPercent,
+		&c.EgressUnicastPercent,
+		&c.EgressMulticastPercent,
+	}
+
+	_, err := readFields(b, fields)
+	if err != nil {
+		return 0, err
+	}
+
+	b = b[4*4:]
+
+	queues := []*[]int32{
+		&c.EgressQueueUnicastPercent,
+		&c.EgressQueueMulticastPercent,
+	}
+
+	for _, queue := range queues {
+		if len(b) < 4 {
+			return 0, ErrDecodingRecord
+		}
+
+		count := binary.BigEndian.Uint32(b)
+		b = b[4:]
+
+		if uint64(count)*4 > uint64(len(b)) {
+			return 0, ErrDecodingRecord
+		}
+
+		*queue = make([]int32, count)
+		for i := range *queue {
+			(*queue)[i] = int32(binary.BigEndian.Uint32(b))
+			b = b[4:]
+		}
+	}
+
+	return size - len(b), nil
+}
+
+func encodeBroadcomPortBuffersRecord(w io.Writer, rec Record) error {
+	c, ok := rec.(BroadcomPortBuffers)
+	if !ok {
+		return ErrEncodingRecord
+	}
+
+	err := binary.Write(w, binary.BigEndian, []int32{
+		c.IngressUnicastPercent,
+		c.IngressMulticastPercent,
+		c.EgressUnicastPercent,
+		c.EgressMulticastPercent,
+	})
 	if err != nil {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	for _, queue := range [][]int32{c.EgressQueueUnicastPercent, c.EgressQueueMulticastPercent} {
+		err = binary.Write(w, binary.BigEndian, uint32(len(queue)))
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(w, binary.BigEndian, queue)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordType returns the type of counter record.
+func (c BroadcomTables) RecordType() DataFormat {
+	return TypeBroadcomTablesRecord
+}
+
+func decodeBroadcomTablesRecord(b []byte, c *BroadcomTables) (int, error) {
+	fields := []interface{}{
+		&c.HostEntries,
+		&c.HostEntriesMax,
+		&c.IPv4Entries,
+		&c.IPv4EntriesMax,
+		&c.IPv6Entries,
+		&c.IPv6EntriesMax,
+		&c.IPv4IPv6Entries,
+		&c.IPv4IPv6EntriesMax,
+		&c.LongIPv6Entries,
+		&c.LongIPv6EntriesMax,
+		&c.TotalRoutes,
+		&c.TotalRoutesMax,
+		&c.ECMPNextHops,
+		&c.ECMPNextHopsMax,
+		&c.MACEntries,
+		&c.MACEntriesMax,
+		&c.IPv4Neighbors,
+		&c.IPv6Neighbors,
+		&c.IPv4Routes,
+		&c.IPv6Routes,
+		&c.ACLIngressEntries,
+		&c.ACLIngressEntriesMax,
+		&c.ACLIngressCounters,
+		&c.ACLIngressCountersMax,
+		&c.ACLIngressMeters,
+		&c.ACLIngressMetersMax,
+		&c.ACLIngressSlices,
+		&c.ACLIngressSlicesMax,
+		&c.ACLEgressEntries,
+		&c.ACLEgressEntriesMax,
+		&c.ACLEgressCounters,
+		&c.ACLEgressCountersMax,
+		&c.ACLEgressMeters,
+		&c.ACLEgressMetersMax,
+		&c.ACLEgressSlices,
+		&c.ACLEgressSlicesMax,
+	}
+
+	return readFields(b, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c NVMLGPUCounters) RecordType() DataFormat {
+	return TypeNVMLGPUCountersRecord
+}
+
+func decodeNVMLGPUCountersRecord(b []byte, c *NVMLGPUCounters) (int, error) {
+	fields := []interface{}{
+		&c.DeviceCount,
+		&c.Processes,
+		&c.GPUTime,
+		&c.MemoryTime,
+		&c.MemoryTotal,
+		&c.MemoryFree,
+		&c.ECCErrors,
+		&c.Energy,
+		&c.Temperature,
+		&c.FanSpeed,
+	}
+
+	return readFields(b, fields)
 }
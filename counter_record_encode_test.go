@@ -2,6 +2,8 @@ package sflow
 
 import (
 	"bytes"
+	"encoding/binary"
+	"reflect"
 	"testing"
 )
 
@@ -30,7 +32,7 @@ func TestEncodeDecodeGenericInterfaceCountersRecord(t *testing.T) {
 
 	b := &bytes.Buffer{}
 
-	err := rec.encode(b)
+	err := counterRecordFormats.encode(b, rec)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -43,7 +45,7 @@ func TestEncodeDecodeGenericInterfaceCountersRecord(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	decoded, err := decodeGenericInterfaceCountersRecord(b, uint32(b.Len()))
+	decoded, _, err := counterRecordFormats.decode(TypeGenericInterfaceCountersRecord, b.Bytes())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -81,7 +83,7 @@ func TestEncodeDecodeHostCPUCountersRecord(t *testing.T) {
 
 	b := &bytes.Buffer{}
 
-	err := rec.encode(b)
+	err := counterRecordFormats.encode(b, rec)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -94,7 +96,277 @@ func TestEncodeDecodeHostCPUCountersRecord(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	decoded, err := decodeHostCPUCountersRecord(b, uint32(b.Len()))
+	decoded, _, err := counterRecordFormats.decode(TypeHostCPUCountersRecord, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeVirtDiskIOCountersRecord(t *testing.T) {
+	rec := VirtDiskIOCounters{
+		Capacity:      1 << 40,
+		Allocation:    1 << 35,
+		Available:     1 << 39,
+		ReadRequests:  4,
+		BytesRead:     5,
+		WriteRequests: 6,
+		BytesWritten:  7,
+		Errors:        8,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := counterRecordFormats.encode(b, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Len() != 52 {
+		t.Fatalf("expected 52 encoded bytes, got %d", b.Len())
+	}
+
+	decoded, _, err := counterRecordFormats.decode(TypeVirtDiskIOCountersRecord, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeJVMRuntimeRecord(t *testing.T) {
+	rec := JVMRuntime{
+		Name:    "OpenJDK 64-Bit Server VM",
+		Vendor:  "Eclipse Adoptium",
+		Version: "17.0.8+7",
+	}
+
+	b := &bytes.Buffer{}
+
+	err := counterRecordFormats.encode(b, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Len()%4 != 0 {
+		t.Fatalf("expected encoded record to be padded to 4 bytes, got %d bytes", b.Len())
+	}
+
+	decoded, _, err := counterRecordFormats.decode(TypeJVMRuntimeRecord, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeAppOperationsRecord(t *testing.T) {
+	rec := AppOperations{
+		Application:   "payments.checkout",
+		Success:       1000,
+		Other:         1,
+		Timeout:       2,
+		InternalError: 3,
+		BadRequest:    4,
+		NotFound:      5,
+		Unauthorized:  6,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := counterRecordFormats.encode(b, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, _, err := counterRecordFormats.decode(TypeAppOperationsRecord, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+
+}
+
+func TestEncodeDecodeApplicationCountersRecord(t *testing.T) {
+	rec := ApplicationCounters{
+		Application:  "payments.refund",
+		Success:      500,
+		Timeout:      7,
+		Forbidden:    8,
+		TooLarge:     9,
+		Unavailable:  10,
+		Unauthorized: 11,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := counterRecordFormats.encode(b, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if format := DataFormat(binary.BigEndian.Uint32(headerBytes[:4])); format != TypeApplicationCountersRecord {
+		t.Errorf("expected data format %v, got %v", TypeApplicationCountersRecord, format)
+	}
+
+	decoded, _, err := counterRecordFormats.decode(TypeApplicationCountersRecord, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+
+	// The data has the app_operations layout.
+	ops, _, err := counterRecordFormats.decode(TypeAppOperationsRecord, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ops != AppOperations(rec) {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", AppOperations(rec), ops)
+	}
+}
+
+func TestEncodeDecodeTemperatureCountersRecord(t *testing.T) {
+	rec := TemperatureCounters{
+		Minimum: -12,
+		Maximum: 41,
+		Errors:  1,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := counterRecordFormats.encode(b, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, _, err := counterRecordFormats.decode(TypeTemperatureCountersRecord, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeBroadcomPortBuffersRecord(t *testing.T) {
+	rec := BroadcomPortBuffers{
+		IngressUnicastPercent:       1250,
+		IngressMulticastPercent:     10,
+		EgressUnicastPercent:        2500,
+		EgressMulticastPercent:      -1,
+		EgressQueueUnicastPercent:   []int32{100, 200, 300, 400, 500, 600, 700, 800},
+		EgressQueueMulticastPercent: []int32{1, 2},
+	}
+
+	b := &bytes.Buffer{}
+
+	err := counterRecordFormats.encode(b, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, _, err := counterRecordFormats.decode(TypeBroadcomPortBuffersRecord, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(rec, decoded) {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeInfiniBandCountersRecord(t *testing.T) {
+	rec := InfiniBandCounters{
+		TransmitPackets:          123456,
+		ReceivePackets:           654321,
+		SymbolErrors:             1,
+		LinkErrorRecoveries:      2,
+		LinkDowned:               3,
+		ReceiveErrors:            4,
+		TransmitDiscards:         5,
+		LocalLinkIntegrityErrors: 6,
+		VL15Dropped:              7,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := counterRecordFormats.encode(b, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, _, err := counterRecordFormats.decode(TypeInfiniBandCountersRecord, b.Bytes())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3,34 +3,61 @@ package sflow
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
 )
 
 const (
-	TypeGenericInterfaceCountersRecord = 1
-	TypeEthernetCountersRecord         = 2
-	TypeTokenRingCountersRecord        = 3
-	TypeVgCountersRecord               = 4
-	TypeVlanCountersRecord             = 5
-
-	TypeProcessorCountersRecord  = 1001
-	TypeHostCPUCountersRecord    = 2003
-	TypeHostMemoryCountersRecord = 2004
-	TypeHostDiskCountersRecord   = 2005
-	TypeHostNetCountersRecord    = 2006
+	TypeGenericInterfaceCountersRecord DataFormat = 1
+	TypeEthernetCountersRecord         DataFormat = 2
+	TypeTokenRingCountersRecord        DataFormat = 3
+	TypeVgCountersRecord               DataFormat = 4
+	TypeVlanCountersRecord             DataFormat = 5
+	TypeInfiniBandCountersRecord       DataFormat = 9
+
+	TypeProcessorCountersRecord  DataFormat = 1001
+	TypeHostCPUCountersRecord    DataFormat = 2003
+	TypeHostMemoryCountersRecord DataFormat = 2004
+	TypeHostDiskCountersRecord   DataFormat = 2005
+	TypeHostNetCountersRecord    DataFormat = 2006
+	TypeMIB2IPCountersRecord     DataFormat = 2007
+	TypeMIB2ICMPCountersRecord   DataFormat = 2008
+	TypeMIB2TCPCountersRecord    DataFormat = 2009
+	TypeMIB2UDPCountersRecord    DataFormat = 2010
+
+	TypeVirtNodeCountersRecord   DataFormat = 2100
+	TypeVirtCPUCountersRecord    DataFormat = 2101
+	TypeVirtMemoryCountersRecord DataFormat = 2102
+	TypeVirtDiskIOCountersRecord DataFormat = 2103
+	TypeVirtNetIOCountersRecord  DataFormat = 2104
+
+	TypeJVMRuntimeRecord    DataFormat = 2105
+	TypeJVMStatisticsRecord DataFormat = 2106
+	TypeHTTPCountersRecord  DataFormat = 2201
+	TypeAppOperationsRecord DataFormat = 2202
+	TypeAppResourcesRecord  DataFormat = 2203
+	TypeAppWorkersRecord    DataFormat = 2206
+
+	TypeEnergyCountersRecord      DataFormat = 3000
+	TypeTemperatureCountersRecord DataFormat = 3001
+	TypeHumidityCountersRecord    DataFormat = 3002
+	TypeFanCountersRecord         DataFormat = 3003
 
 	// Custom (Enterprise) types
-	TypeApplicationCountersRecord = (1)<<12 + 1
+	TypeApplicationCountersRecord DataFormat = (1)<<12 + 1
+
+	TypeBroadcomDeviceBuffersRecord DataFormat = (4413)<<12 + 1
+	TypeBroadcomPortBuffersRecord   DataFormat = (4413)<<12 + 2
+	TypeBroadcomTablesRecord        DataFormat = (4413)<<12 + 3
+
+	TypeNVMLGPUCountersRecord DataFormat = (5703)<<12 + 1
 )
 
 type CounterSample struct {
-	SequenceNum      uint32
-	SourceIdType     byte
-	SourceIdIndexVal uint32 // NOTE: this is 3 bytes in the datagram
-	numRecords       uint32
-	Records          []Record
+	SequenceNum uint32
+	Source      DataSource
+	numRecords  uint32
+	Records     []Record
 }
 
 func (s CounterSample) String() string {
@@ -40,7 +67,7 @@ func (s CounterSample) String() string {
 }
 
 // SampleType returns the type of sFlow sample.
-func (s *CounterSample) SampleType() int {
+func (s *CounterSample) SampleType() DataFormat {
 	return TypeCounterSample
 }
 
@@ -48,137 +75,74 @@ func (s *CounterSample) GetRecords() []Record {
 	return s.Records
 }
 
-func decodeCounterSample(r io.ReadSeeker) (Sample, error) {
-	s := &CounterSample{}
+// Record returns the i-th record, decoding it if it's a RawRecord.
+func (s *CounterSample) Record(i int) (Record, error) {
+	return counterRecordFormats.record(s.Records, i)
+}
 
-	var err error
+// Validate checks the sample and its records, and returns a
+// *ValidationError listing every violation found.
+func (s *CounterSample) Validate() error {
+	var v violations
 
-	err = binary.Read(r, binary.BigEndian, &s.SequenceNum)
-	if err != nil {
-		return nil, err
-	}
+	v.addRecords(s)
 
-	err = binary.Read(r, binary.BigEndian, &s.SourceIdType)
-	if err != nil {
-		return nil, err
-	}
+	return v.err()
+}
 
-	var srcIdIndexVal [3]byte
-	n, err := r.Read(srcIdIndexVal[:])
-	if err != nil {
-		return nil, err
+func decodeCounterSample(b []byte, prev Sample, st *decodeState) (Sample, int, error) {
+	s, ok := prev.(*CounterSample)
+	if !ok || s == nil {
+		s = &CounterSample{}
 	}
 
-	if n != 3 {
-		return nil, errors.New("sflow: counter sample decoding error")
+	if len(b) < 3*4 {
+		return nil, 0, ErrLengthOverrun
 	}
 
-	s.SourceIdIndexVal = uint32(srcIdIndexVal[2]) |
-		uint32(srcIdIndexVal[1])<<8 |
-		uint32(srcIdIndexVal[0])<<16
+	var source uint32
 
-	err = binary.Read(r, binary.BigEndian, &s.numRecords)
+	fields := []interface{}{
+		&s.SequenceNum,
+		&source,
+		&s.numRecords,
+	}
+
+	_, err := readFields(b, fields)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	for i := uint32(0); i < s.numRecords; i++ {
-		format, length := uint32(0), uint32(0)
+	s.Source = decodeDataSource(source)
 
-		err = binary.Read(r, binary.BigEndian, &format)
-		if err != nil {
-			return nil, err
-		}
+	records, n, err := counterRecordFormats.decodeRecords(b, 3*4, s.numRecords, s.Records, st)
+	if err != nil {
+		return nil, 0, err
+	}
 
-		err = binary.Read(r, binary.BigEndian, &length)
-		if err != nil {
-			return nil, err
-		}
-		if length > MaximumRecordLength {
-			return nil, fmt.Errorf("sflow: record length more than %d: %d",
-				MaximumRecordLength, length)
-		}
+	s.Records = records
 
-		var rec Record
-
-		switch format {
-		case TypeGenericInterfaceCountersRecord:
-			rec, err = decodeGenericInterfaceCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeEthernetCountersRecord:
-			rec, err = decodeEthernetCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeTokenRingCountersRecord:
-			rec, err = decodeTokenRingCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeVgCountersRecord:
-			rec, err = decodeVgCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeVlanCountersRecord:
-			rec, err = decodeVlanCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeProcessorCountersRecord:
-			rec, err = decodeProcessorCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeHostCPUCountersRecord:
-			rec, err = decodeHostCPUCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeHostMemoryCountersRecord:
-			rec, err = decodeHostMemoryCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeHostDiskCountersRecord:
-			rec, err = decodeHostDiskCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeHostNetCountersRecord:
-			rec, err = decodeHostNetCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			_, err := r.Seek(int64(length), 1)
-			if err != nil {
-				return nil, err
-			}
-			continue
-		}
-		s.Records = append(s.Records, rec)
-	}
-	return s, nil
+	return s, n, nil
 }
 
 func (s *CounterSample) encode(w io.Writer) error {
-	var err error
+	source, err := s.Source.compact()
+	if err != nil {
+		return err
+	}
 
 	// We first need to encode the records.
 	buf := &bytes.Buffer{}
 
 	for _, rec := range s.Records {
-		err = rec.encode(buf)
+		err = counterRecordFormats.encode(buf, rec)
 		if err != nil {
 			return ErrEncodingRecord
 		}
 	}
 
 	// Fields
-	encodedSampleSize := uint32(4 + 1 + 3 + 4)
+	encodedSampleSize := uint32(4 + 4 + 4)
 
 	// Encoded records
 	encodedSampleSize += uint32(buf.Len())
@@ -195,8 +159,7 @@ func (s *CounterSample) encode(w io.Writer) error {
 	if err != nil {
 		return err
 	}
-	err = binary.Write(w, binary.BigEndian,
-		uint32(s.SourceIdType)|s.SourceIdIndexVal<<24)
+	err = binary.Write(w, binary.BigEndian, source)
 	if err != nil {
 		return err
 	}
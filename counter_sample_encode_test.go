@@ -2,7 +2,9 @@ package sflow
 
 import (
 	"bytes"
+	"encoding/binary"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -47,8 +49,7 @@ func TestDecodeEncodeAndDecodeCounterSample(t *testing.T) {
 	var skip [8]byte
 	buf.Read(skip[:])
 
-	// bytes.Buffer is not an io.ReadSeeker. bytes.Reader is.
-	decodedSample, err := decodeCounterSample(bytes.NewReader(buf.Bytes()))
+	decodedSample, err := decodeSample(TypeCounterSample, buf.Bytes(), nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -103,3 +104,224 @@ func TestDecodeEncodeAndDecodeCounterSample(t *testing.T) {
 		t.Errorf("expected\n%#v, got\n%#v", expectedGenericInterfaceCounters, genericInterfaceCounters)
 	}
 }
+
+func TestEncodeDecodeMIB2CounterSample(t *testing.T) {
+	tcp := MIB2TCPCounters{
+		RtoAlgorithm:          1,
+		RtoMin:                200,
+		RtoMax:                120000,
+		MaxConnections:        0xffffffff,
+		ActiveOpens:           1234,
+		PassiveOpens:          567,
+		AttemptFails:          8,
+		EstablishedResets:     9,
+		CurrentEstablished:    10,
+		InSegments:            98765,
+		OutSegments:           87654,
+		RetransmittedSegments: 321,
+		InErrors:              2,
+		OutResets:             3,
+		InChecksumErrors:      1,
+	}
+	udp := MIB2UDPCounters{
+		InDatagrams:  100,
+		NoPorts:      2,
+		InErrors:     1,
+		OutDatagrams: 99,
+	}
+
+	sample := &CounterSample{
+		SequenceNum: 1,
+		Records:     []Record{tcp, udp},
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// We need to skip the first 8 bytes. That's the header.
+	var skip [8]byte
+	buf.Read(skip[:])
+
+	decodedSample, err := decodeSample(TypeCounterSample, buf.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, ok := decodedSample.(*CounterSample)
+	if !ok {
+		t.Fatalf("expected a CounterSample, got %T", decodedSample)
+	}
+
+	if len(decoded.Records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(decoded.Records))
+	}
+
+	if decoded.Records[0] != tcp {
+		t.Errorf("expected\n%#v, got\n%#v", tcp, decoded.Records[0])
+	}
+
+	if decoded.Records[1] != udp {
+		t.Errorf("expected\n%#v, got\n%#v", udp, decoded.Records[1])
+	}
+}
+
+func TestEncodeDecodeMIB2IPAndICMPCounterSample(t *testing.T) {
+	// Each field is numbered in the order of the spec, so that
+	// the encoded data is 1, 2, 3, and so on.
+	ip := MIB2IPCounters{}
+	icmp := MIB2ICMPCounters{}
+
+	for _, rec := range []interface{}{&ip, &icmp} {
+		v := reflect.ValueOf(rec).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			v.Field(i).SetUint(uint64(i + 1))
+		}
+	}
+
+	// The ICMP group has no input counter of timestamp replies.
+	if ip.FragmentCreates != 19 || icmp.InAddressMaskReplies != 12 ||
+		icmp.OutMessages != 13 || icmp.OutTimestampReplies != 23 ||
+		icmp.OutAddressMaskReplies != 25 {
+		t.Fatalf("unexpected field order: %v, %v", ip, icmp)
+	}
+
+	sample := &CounterSample{
+		SequenceNum: 1,
+		Records:     []Record{ip, icmp},
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The records follow the sample header and fields.
+	b := buf.Bytes()[2*4+3*4:]
+
+	for _, n := range []int{19, 25} {
+		if binary.BigEndian.Uint32(b[4:]) != uint32(4*n) {
+			t.Fatalf("expected a record of %d bytes, got %d", 4*n, binary.BigEndian.Uint32(b[4:]))
+		}
+
+		for i := 0; i < n; i++ {
+			v := binary.BigEndian.Uint32(b[2*4+4*i:])
+			if v != uint32(i+1) {
+				t.Errorf("expected field %d to be encoded as %d, got %d", i, i+1, v)
+			}
+		}
+
+		b = b[2*4+4*n:]
+	}
+
+	decodedSample, err := decodeSample(TypeCounterSample, buf.Bytes()[2*4:], nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := decodedSample.GetRecords()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	if records[0] != ip {
+		t.Errorf("expected\n%#v, got\n%#v", ip, records[0])
+	}
+
+	if records[1] != icmp {
+		t.Errorf("expected\n%#v, got\n%#v", icmp, records[1])
+	}
+}
+
+func TestEncodeDecodeNVMLGPUCounterSample(t *testing.T) {
+	gpu := NVMLGPUCounters{
+		DeviceCount: 4,
+		Processes:   7,
+		GPUTime:     3500,
+		MemoryTime:  1200,
+		MemoryTotal: 4 * 80 << 30,
+		MemoryFree:  100 << 30,
+		ECCErrors:   0,
+		Energy:      1500000,
+		Temperature: 71,
+		FanSpeed:    65,
+	}
+
+	sample := &CounterSample{
+		SequenceNum: 1,
+		Records:     []Record{gpu},
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// We need to skip the first 8 bytes. That's the header.
+	var skip [8]byte
+	buf.Read(skip[:])
+
+	decodedSample, err := decodeSample(TypeCounterSample, buf.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, ok := decodedSample.(*CounterSample)
+	if !ok {
+		t.Fatalf("expected a CounterSample, got %T", decodedSample)
+	}
+
+	if len(decoded.Records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(decoded.Records))
+	}
+
+	if decoded.Records[0] != gpu {
+		t.Errorf("expected\n%#v, got\n%#v", gpu, decoded.Records[0])
+	}
+}
+
+func TestDecodeEncodeAndDecodeUnknownRecords(t *testing.T) {
+	f, err := os.Open("_test/host_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(f)
+
+	dgram, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample, ok := dgram.Samples[0].(*CounterSample)
+	if !ok {
+		t.Fatalf("expected a CounterSample, got %T", dgram.Samples[0])
+	}
+
+	buf := &bytes.Buffer{}
+
+	err = sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// We need to skip the first 8 bytes. That's the header.
+	var skip [8]byte
+	buf.Read(skip[:])
+
+	decodedSample, err := decodeSample(TypeCounterSample, buf.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(sample.Records, decodedSample.GetRecords()) {
+		t.Errorf("expected\n%v, got\n%v", sample.Records, decodedSample.GetRecords())
+	}
+}
@@ -0,0 +1,36 @@
+package sflow
+
+import "fmt"
+
+// DataFormat identifies the format of a sample or record. The upper
+// 20 bits are an IANA-assigned enterprise number, and the lower 12 bits
+// are a format number defined by that enterprise. Formats defined by
+// sFlow.org use enterprise 0.
+type DataFormat uint32
+
+// NewDataFormat returns the data format with the given enterprise
+// and format numbers. It panics if enterprise doesn't fit in 20 bits
+// or format doesn't fit in 12 bits.
+func NewDataFormat(enterprise, format uint32) DataFormat {
+	if enterprise >= 1<<20 || format >= 1<<12 {
+		panic(fmt.Sprintf("sflow: invalid data format %d:%d", enterprise, format))
+	}
+
+	return DataFormat(enterprise<<12 | format)
+}
+
+// Enterprise returns the enterprise number of f.
+func (f DataFormat) Enterprise() uint32 {
+	return uint32(f) >> 12
+}
+
+// Format returns the format number of f.
+func (f DataFormat) Format() uint32 {
+	return uint32(f) & (1<<12 - 1)
+}
+
+// String returns f as "enterprise:format", e.g. "0:2003",
+// which is how sflowtool prints sample and record tags.
+func (f DataFormat) String() string {
+	return fmt.Sprintf("%d:%d", f.Enterprise(), f.Format())
+}
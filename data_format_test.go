@@ -0,0 +1,35 @@
+package sflow
+
+import "testing"
+
+func TestDataFormat(t *testing.T) {
+	cases := []struct {
+		dataFormat DataFormat
+		enterprise uint32
+		format     uint32
+		str        string
+	}{
+		{TypeHostCPUCountersRecord, 0, 2003, "0:2003"},
+		{TypeApplicationCountersRecord, 1, 1, "1:1"},
+		{TypeBroadcomTablesRecord, 4413, 3, "4413:3"},
+		{NewDataFormat(1<<20-1, 1<<12-1), 1<<20 - 1, 1<<12 - 1, "1048575:4095"},
+	}
+
+	for _, c := range cases {
+		if c.dataFormat.Enterprise() != c.enterprise {
+			t.Errorf("%v: expected enterprise %d, got %d", c.dataFormat, c.enterprise, c.dataFormat.Enterprise())
+		}
+
+		if c.dataFormat.Format() != c.format {
+			t.Errorf("%v: expected format %d, got %d", c.dataFormat, c.format, c.dataFormat.Format())
+		}
+
+		if c.dataFormat.String() != c.str {
+			t.Errorf("expected %q, got %q", c.str, c.dataFormat.String())
+		}
+
+		if NewDataFormat(c.enterprise, c.format) != c.dataFormat {
+			t.Errorf("expected NewDataFormat(%d, %d) to be %v", c.enterprise, c.format, c.dataFormat)
+		}
+	}
+}
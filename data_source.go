@@ -0,0 +1,49 @@
+package sflow
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DataSourceType is the class of a data source,
+// which determines what its index refers to.
+type DataSourceType uint32
+
+const (
+	DataSourceIfIndex     DataSourceType = 0 // ifIndex
+	DataSourceSmonVlan    DataSourceType = 1 // smonVlanDataSource
+	DataSourceEntPhysical DataSourceType = 2 // entPhysicalEntry
+)
+
+var ErrDataSourceOverflow = errors.New("sflow: data source too large for compact encoding")
+
+// DataSource identifies the data source of a sample,
+// e.g. the interface a packet was sampled on.
+type DataSource struct {
+	Type  DataSourceType
+	Index uint32
+}
+
+// String returns s as "type:index", e.g. "0:17".
+func (s DataSource) String() string {
+	return fmt.Sprintf("%d:%d", s.Type, s.Index)
+}
+
+// decodeDataSource decodes a data source from its compact
+// representation, which has the type in the upper 8 bits
+// and the index in the lower 24 bits.
+func decodeDataSource(v uint32) DataSource {
+	return DataSource{
+		Type:  DataSourceType(v >> 24),
+		Index: v & (1<<24 - 1),
+	}
+}
+
+// compact returns the compact representation of s.
+func (s DataSource) compact() (uint32, error) {
+	if s.Type >= 1<<8 || s.Index >= 1<<24 {
+		return 0, ErrDataSourceOverflow
+	}
+
+	return uint32(s.Type)<<24 | s.Index, nil
+}
@@ -0,0 +1,29 @@
+package sflow
+
+import "testing"
+
+func TestDataSource(t *testing.T) {
+	source := DataSource{Type: DataSourceEntPhysical, Index: 17}
+
+	if source.String() != "2:17" {
+		t.Errorf("expected %q, got %q", "2:17", source.String())
+	}
+
+	v, err := source.compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v != 0x02000011 {
+		t.Errorf("expected compact data source %#x, got %#x", 0x02000011, v)
+	}
+
+	if decodeDataSource(v) != source {
+		t.Errorf("expected %v, got %v", source, decodeDataSource(v))
+	}
+
+	_, err = DataSource{Index: 1 << 24}.compact()
+	if err != ErrDataSourceOverflow {
+		t.Errorf("expected %v, got %v", ErrDataSourceOverflow, err)
+	}
+}
@@ -6,14 +6,26 @@ import (
 )
 
 type Datagram struct {
-	Version        uint32   `json:"version"`
-	IpVersion      uint32   `json:"ipVersion"`
-	IpAddress      net.IP   `json:"ipAddress"`
-	SubAgentId     uint32   `json:"subAgentId"`
-	SequenceNumber uint32   `json:"sequenceNumber"`
-	Uptime         uint32   `json:"uptime"`
-	NumSamples     uint32   `json:"numSamples"`
-	Samples        []Sample `json:"samples"`
+	Version        uint32      `json:"version"`
+	IpVersion      AddressType `json:"ipVersion"`
+	IpAddress      net.IP      `json:"ipAddress"`
+	SubAgentId     uint32      `json:"subAgentId"`
+	SequenceNumber uint32      `json:"sequenceNumber"`
+	Uptime         uint32      `json:"uptime"`
+	NumSamples     uint32      `json:"numSamples"`
+	Samples        []Sample    `json:"samples"`
+
+	// Errors are the errors of the samples and records skipped
+	// by a lenient decoder. See DecoderOptions.
+	Errors []error `json:"-"`
+
+	// buf is the buffer Decoder.DecodeInto reads the datagram
+	// into, which is reused by the next call.
+	buf []byte
+
+	// records holds the records decoded into the datagram,
+	// which are decoded into again by the next call.
+	records recordStore
 }
 
 func (d Datagram) String() string {
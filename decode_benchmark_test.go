@@ -1,6 +1,7 @@
 package sflow
 
 import (
+	"bytes"
 	"os"
 	"testing"
 )
@@ -34,3 +35,86 @@ func BenchmarkCounterSample(b *testing.B) {
 
 	f.Close()
 }
+
+func BenchmarkDecodeBytesFlow1Sample(b *testing.B) {
+	buf, err := os.ReadFile("_test/flow_sample.dump")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, err = DecodeBytesNoCopy(buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeBytesCounterSample(b *testing.B) {
+	buf, err := os.ReadFile("_test/counter_sample.dump")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, err = DecodeBytesNoCopy(buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkDecodeInto benchmarks decoding the datagram in dump into
+// the same datagram, and fails if that allocates.
+func benchmarkDecodeInto(b *testing.B, dump string, options DecoderOptions) {
+	buf, err := os.ReadFile(dump)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	r := bytes.NewReader(buf)
+	d := NewDecoder(r)
+	d.SetOptions(options)
+	dgram := &Datagram{}
+
+	decode := func() {
+		r.Reset(buf)
+
+		err := d.DecodeInto(dgram)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// The first datagram decoded allocates the samples, records
+	// and buffer reused afterwards.
+	decode()
+
+	allocs := testing.AllocsPerRun(100, decode)
+	if allocs != 0 {
+		b.Fatalf("expected no allocations, got %v", allocs)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		decode()
+	}
+}
+
+func BenchmarkDecodeIntoFlow1Sample(b *testing.B) {
+	benchmarkDecodeInto(b, "_test/flow_sample.dump", DecoderOptions{})
+}
+
+func BenchmarkDecodeIntoCounterSample(b *testing.B) {
+	benchmarkDecodeInto(b, "_test/counter_sample.dump", DecoderOptions{})
+}
+
+func BenchmarkDecodeIntoLazyCounterSample(b *testing.B) {
+	benchmarkDecodeInto(b, "_test/counter_sample.dump", DecoderOptions{Lazy: true})
+}
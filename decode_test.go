@@ -1,8 +1,17 @@
 package sflow
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestDecodeGenericEthernetCounterSample(t *testing.T) {
@@ -111,8 +120,20 @@ func TestDecodeHostCounters(t *testing.T) {
 		t.Fatalf("expected a CounterSample, got %T", dgram.Samples[0])
 	}
 
-	if len(sample.Records) != 4 {
-		t.Fatalf("expected 4 records, got %d", len(sample.Records))
+	if len(sample.Records) != 6 {
+		t.Fatalf("expected 6 records, got %d", len(sample.Records))
+	}
+
+	// host_descr and host_adapters aren't decoded.
+	for i, dataFormat := range map[int]DataFormat{0: 2001, 5: 2000} {
+		rec, ok := sample.Records[i].(UnknownRecord)
+		if !ok {
+			t.Fatalf("expected an UnknownRecord, got %T", sample.Records[i])
+		}
+
+		if rec.DataFormat != dataFormat {
+			t.Errorf("expected data format %v, got %v", dataFormat, rec.DataFormat)
+		}
 	}
 
 	// TODO: check values
@@ -148,6 +169,14 @@ func TestDecodeFlow1(t *testing.T) {
 		t.Fatalf("expected a FlowSample, got %T", dgram.Samples[0])
 	}
 
+	if sample.Input.Index() != 4 {
+		t.Errorf("expected Input to be 4, got %v", sample.Input)
+	}
+
+	if sample.Output.Index() != 1 {
+		t.Errorf("expected Output to be 1, got %v", sample.Output)
+	}
+
 	if len(sample.Records) != 2 {
 		t.Fatalf("expected 2 records, got %d", len(sample.Records))
 	}
@@ -205,3 +234,871 @@ func TestDecodeEventDiscardedPacket(t *testing.T) {
 	}
 
 }
+
+func TestDecodeBytes(t *testing.T) {
+	dumps := []string{
+		"_test/counter_sample.dump",
+		"_test/event_discarded_packet.dump",
+		"_test/flow_sample.dump",
+		"_test/flow_sample_3.dump",
+		"_test/flow_samples_2.dump",
+		"_test/host_sample.dump",
+	}
+
+	for _, dump := range dumps {
+		b, err := os.ReadFile(dump)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := bytes.NewReader(b)
+
+		expected, err := NewDecoder(r).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Some dumps have more than one datagram, which
+		// are trailing bytes to DecodeBytes.
+		n := len(b) - r.Len()
+		if n < len(b) {
+			_, err = DecodeBytes(b)
+
+			var lengthErr *LengthError
+			if !errors.As(err, &lengthErr) || lengthErr.Trailing != len(b)-n {
+				t.Errorf("%s: expected %d trailing bytes, got %v", dump, len(b)-n, err)
+			}
+		}
+
+		dgram, err := DecodeBytes(b[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(dgram, expected) {
+			t.Errorf("%s: expected\n%v, got\n%v", dump, expected, dgram)
+		}
+
+		_, err = DecodeBytes(b[:n-1])
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: expected %v for a truncated datagram, got %v", dump, io.ErrUnexpectedEOF, err)
+		}
+	}
+}
+
+func TestDecodeBytesNoCopy(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aliased, err := DecodeBytesNoCopy(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copiedHeader := copied.Samples[0].GetRecords()[0].(RawPacketFlow).Header
+	aliasedHeader := aliased.Samples[0].GetRecords()[0].(RawPacketFlow).Header
+
+	// Modifying b must only change the header that aliases it.
+	original := copiedHeader[0]
+	for i := range b {
+		b[i] ^= 0xff
+	}
+
+	if copiedHeader[0] != original {
+		t.Errorf("expected DecodeBytes header not to reference the input")
+	}
+
+	if aliasedHeader[0] != original^0xff {
+		t.Errorf("expected DecodeBytesNoCopy header to reference the input")
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	dumps := []string{
+		"_test/counter_sample.dump",
+		"_test/event_discarded_packet.dump",
+		"_test/flow_sample.dump",
+		"_test/flow_sample_3.dump",
+		"_test/host_sample.dump",
+	}
+
+	var stream []byte
+	var expected []*Datagram
+
+	for _, dump := range dumps {
+		b, err := os.ReadFile(dump)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dgram, err := DecodeBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stream = append(stream, b...)
+		expected = append(expected, dgram)
+	}
+
+	// Decoding the stream twice decodes each datagram into
+	// one of a different shape, and then into itself.
+	d := NewDecoder(bytes.NewReader(append(stream, stream...)))
+	dgram := &Datagram{}
+
+	for i := 0; i < 2*len(dumps); i++ {
+		err := d.DecodeInto(dgram)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Records are decoded into the datagram as pointers,
+		// and encode like the records they point to.
+		want := expected[i%len(dumps)]
+		wantBytes, gotBytes := &bytes.Buffer{}, &bytes.Buffer{}
+
+		err = NewEncoder(want.IpAddress, want.SubAgentId, want.SequenceNumber).Encode(wantBytes, want.Samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = NewEncoder(dgram.IpAddress, dgram.SubAgentId, dgram.SequenceNumber).Encode(gotBytes, dgram.Samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(gotBytes.Bytes(), wantBytes.Bytes()) {
+			t.Errorf("%s: expected encoding\n%v, got\n%v", dumps[i%len(dumps)], wantBytes.Bytes(), gotBytes.Bytes())
+		}
+
+		got := *dgram
+		got.buf = nil
+		got.records = recordStore{}
+		got.Samples = recordValues(t, got.Samples)
+
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("%s: expected\n%v, got\n%v", dumps[i%len(dumps)], want, got)
+		}
+	}
+
+	_, err := d.Decode()
+	if err != io.EOF {
+		t.Errorf("expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestDecodeIntoReusesSamples(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgram := &Datagram{}
+
+	err = DecodeBytesInto(b, dgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample := dgram.Samples[0]
+
+	err = DecodeBytesInto(b, dgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dgram.Samples[0] != sample {
+		t.Errorf("expected the sample to be decoded into again")
+	}
+
+	record := dgram.Samples[0].GetRecords()[0].(*RawPacketFlow)
+
+	err = DecodeBytesInto(b, dgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dgram.Samples[0].GetRecords()[0] != record {
+		t.Errorf("expected the record to be decoded into again")
+	}
+}
+
+// recordValues returns copies of samples whose records are values
+// rather than pointers, as decoded into a datagram.
+func recordValues(t *testing.T, samples []Sample) []Sample {
+	var values []Sample
+
+	for _, sample := range samples {
+		var records *[]Record
+
+		switch s := sample.(type) {
+		case *FlowSample:
+			c := *s
+			sample, records = &c, &c.Records
+		case *CounterSample:
+			c := *s
+			sample, records = &c, &c.Records
+		case *ExpandedFlowSample:
+			c := *s
+			sample, records = &c, &c.Records
+		case *EventDiscardedPacket:
+			c := *s
+			sample, records = &c, &c.Records
+		}
+
+		if records != nil {
+			values := make([]Record, len(*records))
+
+			for i, rec := range *records {
+				v := reflect.ValueOf(rec)
+				if v.Kind() != reflect.Pointer {
+					t.Errorf("expected a pointer record, got %T", rec)
+					continue
+				}
+
+				values[i] = v.Elem().Interface().(Record)
+			}
+
+			*records = values
+		}
+
+		values = append(values, sample)
+	}
+
+	return values
+}
+
+func TestDecodePooled(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(bytes.NewReader(b))
+
+	dgram, err := d.DecodePooled()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if samples := recordValues(t, dgram.Samples); !reflect.DeepEqual(samples, expected.Samples) {
+		t.Errorf("expected\n%v, got\n%v", expected.Samples, samples)
+	}
+
+	ReleaseDatagram(dgram)
+
+	_, err = d.DecodePooled()
+	if err != io.EOF {
+		t.Errorf("expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestDecodeReader(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The reader can't seek and returns a byte at a time.
+	d := NewDecoder(iotest.OneByteReader(bytes.NewReader(b)))
+
+	dgram, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(dgram, expected) {
+		t.Errorf("expected\n%v, got\n%v", expected, dgram)
+	}
+}
+
+func TestDecodeReaderMaximumLength(t *testing.T) {
+	// A datagram claiming many samples of 32 KiB each.
+	sample := make([]byte, 2*4+32<<10)
+	binary.BigEndian.PutUint32(sample, 99)
+	binary.BigEndian.PutUint32(sample[4:], 32<<10)
+
+	b := binary.BigEndian.AppendUint32(nil, 5)
+	b = binary.BigEndian.AppendUint32(b, uint32(AddressTypeIPv4))
+	b = append(b, 127, 0, 0, 1)
+	b = append(b, make([]byte, 3*4)...)
+	b = binary.BigEndian.AppendUint32(b, 1<<20)
+
+	for i := 0; i < 3; i++ {
+		b = append(b, sample...)
+	}
+
+	_, err := NewDecoder(bytes.NewReader(b)).Decode()
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Sample != 1 ||
+		!strings.Contains(err.Error(), "datagram length more than 65536") {
+		t.Errorf("expected the second sample to exceed the maximum length, got %v", err)
+	}
+}
+
+func TestDecodeFramed(t *testing.T) {
+	dumps := []string{
+		"_test/counter_sample.dump",
+		"_test/flow_sample.dump",
+		"_test/host_sample.dump",
+	}
+
+	// Frames are decoded from a compressed stream.
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+
+	var expected []*Datagram
+
+	for _, dump := range dumps {
+		b, err := os.ReadFile(dump)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dgram, err := DecodeBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected = append(expected, dgram)
+
+		binary.Write(w, binary.BigEndian, uint32(len(b)))
+		w.Write(b)
+	}
+
+	w.Close()
+
+	r, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewFramedDecoder(r)
+
+	for i := range dumps {
+		dgram, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(dgram, expected[i]) {
+			t.Errorf("%s: expected\n%v, got\n%v", dumps[i], expected[i], dgram)
+		}
+	}
+
+	_, err = d.Decode()
+	if err != io.EOF {
+		t.Errorf("expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestDecodeFramedTruncated(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, uint32(len(b)))
+	buf.Write(b[:len(b)-1])
+
+	_, err = NewFramedDecoder(buf).Decode()
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+
+	buf.Reset()
+	binary.Write(buf, binary.BigEndian, uint32(MaximumFrameLength+1))
+
+	_, err = NewFramedDecoder(buf).Decode()
+	if err == nil {
+		t.Errorf("expected an error for a frame longer than %d", MaximumFrameLength)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample_3.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgram, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first record of the last sample is 8*4 bytes into
+	// the sample data.
+	sample := len(dgram.Samples) - 1
+	offset := sampleOffsets(b, dgram)[sample] + 2*4 + 8*4
+
+	// Shorten the record, so that its fields overrun it.
+	b = append([]byte(nil), b...)
+	binary.BigEndian.PutUint32(b[offset+4:], 2)
+
+	_, err = DecodeBytes(b)
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected a *DecodeError, got %v", err)
+	}
+
+	if decodeErr.Offset != offset {
+		t.Errorf("expected offset %d, got %d", offset, decodeErr.Offset)
+	}
+
+	if decodeErr.Sample != sample || decodeErr.Record != 0 {
+		t.Errorf("expected sample %d, record 0, got sample %d, record %d", sample, decodeErr.Sample, decodeErr.Record)
+	}
+
+	if decodeErr.DataFormat != TypeRawPacketFlowRecord {
+		t.Errorf("expected data format %v, got %v", TypeRawPacketFlowRecord, decodeErr.DataFormat)
+	}
+
+	if !decodeErr.Agent.Equal(dgram.IpAddress) {
+		t.Errorf("expected agent %v, got %v", dgram.IpAddress, decodeErr.Agent)
+	}
+
+	if !errors.Is(err, ErrLengthOverrun) {
+		t.Errorf("expected %v, got %v", ErrLengthOverrun, decodeErr.Err)
+	}
+
+	b[0] = 4
+
+	_, err = DecodeBytes(b)
+	if !errors.Is(err, ErrUnsupportedDatagramVersion) {
+		t.Errorf("expected %v, got %v", ErrUnsupportedDatagramVersion, err)
+	}
+}
+
+// sampleOffsets returns the offsets of the samples of dgram,
+// which is decoded from b.
+func sampleOffsets(b []byte, dgram *Datagram) []int {
+	var offsets []int
+
+	offset := 2*4 + len(dgram.IpAddress) + 4*4
+	for range dgram.Samples {
+		offsets = append(offsets, offset)
+		offset += 2*4 + int(binary.BigEndian.Uint32(b[offset+4:]))
+	}
+
+	return offsets
+}
+
+func TestDecodeLenient(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample_3.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offsets := sampleOffsets(b, expected)
+	b = append([]byte(nil), b...)
+
+	// The header of the first record of the first sample is too long.
+	binary.BigEndian.PutUint32(b[offsets[0]+2*4+8*4+2*4+3*4:], MaximumHeaderLength+1)
+
+	// The second sample has more records than it contains.
+	numRecords := binary.BigEndian.Uint32(b[offsets[1]+2*4+7*4:])
+	binary.BigEndian.PutUint32(b[offsets[1]+2*4+7*4:], numRecords+1)
+
+	_, err = NewDecoder(bytes.NewReader(b)).Decode()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	d := NewDecoder(bytes.NewReader(b))
+	d.SetOptions(DecoderOptions{Lenient: true})
+
+	dgram, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", dgram.Errors)
+	}
+
+	errs := []struct {
+		offset int
+		sample int
+		record int
+	}{
+		{offsets[0] + 2*4 + 8*4, 0, 0},
+		{offsets[1], 1, -1},
+	}
+
+	for i, e := range errs {
+		var decodeErr *DecodeError
+		if !errors.As(dgram.Errors[i], &decodeErr) {
+			t.Fatalf("expected a *DecodeError, got %v", dgram.Errors[i])
+		}
+
+		if decodeErr.Offset != e.offset || decodeErr.Sample != e.sample || decodeErr.Record != e.record {
+			t.Errorf("expected an error at offset %d, sample %d, record %d, got %v",
+				e.offset, e.sample, e.record, decodeErr)
+		}
+	}
+
+	// The second sample is skipped, and the first without its first record.
+	if len(dgram.Samples) != len(expected.Samples)-1 {
+		t.Fatalf("expected %d samples, got %d", len(expected.Samples)-1, len(dgram.Samples))
+	}
+
+	if !reflect.DeepEqual(dgram.Samples[0].GetRecords(), expected.Samples[0].GetRecords()[1:]) {
+		t.Errorf("expected records\n%v, got\n%v", expected.Samples[0].GetRecords()[1:], dgram.Samples[0].GetRecords())
+	}
+
+	if !reflect.DeepEqual(dgram.Samples[1:], expected.Samples[2:]) {
+		t.Errorf("expected samples\n%v, got\n%v", expected.Samples[2:], dgram.Samples[1:])
+	}
+
+	// A truncated sample is skipped with the samples following it.
+	frame := binary.BigEndian.AppendUint32(nil, uint32(offsets[2]+2*4))
+	frame = append(frame, b[:offsets[2]+2*4]...)
+
+	d = NewFramedDecoder(bytes.NewReader(frame))
+	d.SetOptions(DecoderOptions{Lenient: true})
+
+	dgram, err = d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Samples) != 1 || len(dgram.Errors) != 3 {
+		t.Fatalf("expected 1 sample and 3 errors, got %d and %v", len(dgram.Samples), dgram.Errors)
+	}
+
+	if !errors.Is(dgram.Errors[2], io.ErrUnexpectedEOF) {
+		t.Errorf("expected %v, got %v", io.ErrUnexpectedEOF, dgram.Errors[2])
+	}
+}
+
+// mixedDatagram returns a datagram with the samples of the flow
+// and counter sample dumps.
+func mixedDatagram(t *testing.T) []byte {
+	var samples []Sample
+
+	for _, name := range []string{"_test/flow_sample.dump", "_test/counter_sample.dump"} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dgram, err := DecodeBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		samples = append(samples, dgram.Samples...)
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := NewEncoder(net.IPv4(127, 0, 0, 1), 0, 1).Encode(buf, samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecodeSelective(t *testing.T) {
+	b := mixedDatagram(t)
+
+	expected, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := expected.Samples
+
+	d := NewDecoder(bytes.NewReader(b))
+	d.SetOptions(DecoderOptions{
+		SampleTypes:     []DataFormat{TypeFlowSample},
+		FlowRecordTypes: []DataFormat{TypeRawPacketFlowRecord},
+	})
+
+	dgram, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dgram.NumSamples != 2 || len(dgram.Samples) != 1 {
+		t.Fatalf("expected 1 of 2 samples, got %d of %d", len(dgram.Samples), dgram.NumSamples)
+	}
+
+	records := dgram.Samples[0].GetRecords()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	if !reflect.DeepEqual(records[0], samples[0].GetRecords()[0]) {
+		t.Errorf("expected\n%v, got\n%v", samples[0].GetRecords()[0], records[0])
+	}
+
+	d.Use(bytes.NewReader(b))
+	d.SetOptions(DecoderOptions{SampleTypes: []DataFormat{TypeCounterSample}})
+
+	dgram, err = d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Samples) != 1 || !reflect.DeepEqual(dgram.Samples[0], samples[1]) {
+		t.Errorf("expected\n%v, got\n%v", samples[1:], dgram.Samples)
+	}
+
+	// The header of the raw packet record is 128 bytes.
+	cases := []struct {
+		options DecoderOptions
+		err     string
+	}{
+		{DecoderOptions{MaximumHeaderLength: 127}, "header length more than 127"},
+		{DecoderOptions{MaximumRecordLength: 64}, "length more than 64"},
+		{DecoderOptions{MaximumHeaderLength: 128}, ""},
+	}
+
+	for _, c := range cases {
+		d.Use(bytes.NewReader(b))
+		d.SetOptions(c.options)
+
+		_, err = d.Decode()
+		if c.err == "" && err != nil {
+			t.Errorf("%+v: expected no error, got %v", c.options, err)
+		}
+
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%+v: expected an error containing %q, got %v", c.options, c.err, err)
+		}
+	}
+}
+
+func TestDecodeLazy(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(bytes.NewReader(b))
+	d.SetOptions(DecoderOptions{Lazy: true})
+
+	dgram, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample := dgram.Samples[0]
+	records := sample.GetRecords()
+
+	for i, rec := range records {
+		raw, ok := rec.(RawRecord)
+		if !ok {
+			t.Fatalf("expected a RawRecord, got %T", rec)
+		}
+
+		if raw.DataFormat != expected.Samples[0].GetRecords()[i].RecordType() {
+			t.Errorf("expected data format %v, got %v", expected.Samples[0].GetRecords()[i].RecordType(), raw.DataFormat)
+		}
+	}
+
+	// Lazy records are encoded as is.
+	lazyBuf := &bytes.Buffer{}
+	expectedBuf := &bytes.Buffer{}
+
+	err = sample.encode(lazyBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = expected.Samples[0].encode(expectedBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(lazyBuf.Bytes(), expectedBuf.Bytes()) {
+		t.Errorf("expected\n%x, got\n%x", expectedBuf.Bytes(), lazyBuf.Bytes())
+	}
+
+	for i := range records {
+		rec, err := sample.Record(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(rec, expected.Samples[0].GetRecords()[i]) {
+			t.Errorf("expected\n%v, got\n%v", expected.Samples[0].GetRecords()[i], rec)
+		}
+	}
+
+	if !reflect.DeepEqual(sample.GetRecords(), expected.Samples[0].GetRecords()) {
+		t.Errorf("expected the decoded records to replace the raw records, got %v", sample.GetRecords())
+	}
+
+	// The errors of lazy records are returned when they're accessed.
+	// The header of the raw packet record is 128 bytes.
+	d.Use(bytes.NewReader(b))
+	d.SetOptions(DecoderOptions{Lazy: true, MaximumHeaderLength: 64})
+
+	dgram, err = d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dgram.Samples[0].Record(0)
+	if err == nil || !strings.Contains(err.Error(), "header length more than 64") {
+		t.Errorf("expected a header length error, got %v", err)
+	}
+
+	if _, ok := dgram.Samples[0].GetRecords()[0].(RawRecord); !ok {
+		t.Errorf("expected the record to stay a RawRecord, got %T", dgram.Samples[0].GetRecords()[0])
+	}
+}
+
+func TestDecodeUnsupportedAddressType(t *testing.T) {
+	b, err := os.ReadFile("_test/counter_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b = append([]byte(nil), b...)
+	binary.BigEndian.PutUint32(b[4:], 7)
+
+	_, err = DecodeBytes(b)
+
+	var addressTypeErr *AddressTypeError
+	if !errors.As(err, &addressTypeErr) || addressTypeErr.AddressType != 7 {
+		t.Errorf("expected an *AddressTypeError for type 7, got %v", err)
+	}
+
+	_, err = NewDecoder(bytes.NewReader(b)).Decode()
+	if !errors.As(err, &addressTypeErr) || addressTypeErr.AddressType != 7 {
+		t.Errorf("expected an *AddressTypeError for type 7, got %v", err)
+	}
+}
+
+func TestDecodeBytesOptions(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_samples_2.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := NewDecoder(bytes.NewReader(b)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The dump has a second datagram, which are trailing bytes
+	// reported in Errors in lenient mode.
+	options := DecoderOptions{Lenient: true}
+
+	dgram, err := options.DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Errors) != 1 || !errors.Is(dgram.Errors[0], ErrLengthUnderrun) {
+		t.Errorf("expected an underrun error, got %v", dgram.Errors)
+	}
+
+	if !reflect.DeepEqual(dgram.Samples, expected.Samples) {
+		t.Errorf("expected\n%v, got\n%v", expected.Samples, dgram.Samples)
+	}
+
+	options = DecoderOptions{
+		Lenient:         true,
+		Lazy:            true,
+		FlowRecordTypes: []DataFormat{TypeRawPacketFlowRecord},
+	}
+
+	dgram = &Datagram{}
+
+	err = options.DecodeBytesInto(b, dgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sample := range dgram.Samples {
+		records := sample.GetRecords()
+		if len(records) != 1 {
+			t.Fatalf("expected 1 record, got %d", len(records))
+		}
+
+		if _, ok := records[0].(*RawRecord); !ok {
+			t.Errorf("expected a *RawRecord, got %T", records[0])
+		}
+
+		// The record is decoded into the datagram as well.
+		rec, err := sample.Record(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := rec.(*RawPacketFlow); !ok {
+			t.Errorf("expected a *RawPacketFlow, got %T", rec)
+		}
+	}
+}
+
+func TestCallerRawRecord(t *testing.T) {
+	rec := RawPacketFlow{
+		Protocol:    1,
+		FrameLength: 64,
+		HeaderSize:  4,
+		Header:      []byte{1, 2, 3, 4},
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := encodeRawPacketFlow(buf, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A RawRecord built by the caller decodes with the default options.
+	sample := &FlowSample{
+		Records: []Record{RawRecord{DataFormat: TypeRawPacketFlowRecord, Data: buf.Bytes()}},
+	}
+
+	decoded, err := sample.Record(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, rec) {
+		t.Errorf("expected\n%v, got\n%v", rec, decoded)
+	}
+
+	sample.Records[0] = RawRecord{DataFormat: TypeRawPacketFlowRecord, Data: buf.Bytes()[:8]}
+
+	_, err = sample.Record(0)
+	if !errors.Is(err, ErrLengthOverrun) {
+		t.Errorf("expected %v, got %v", ErrLengthOverrun, err)
+	}
+}
@@ -3,7 +3,12 @@ package sflow
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
 )
 
 const (
@@ -11,91 +16,532 @@ const (
 	// This maximum prevents from excessive memory allocation.
 	// The value is derived from MAX_PKT_SIZ 65536 in the reference sFlow implementation
 	// https://github.com/sflow/sflowtool/blob/bd3df6e11bdf/src/sflowtool.c#L4313.
+	// It's the default of DecoderOptions.MaximumRecordLength.
 	MaximumRecordLength = 65536
 
 	// MaximumHeaderLength defines the maximum length acceptable for decoded flow samples.
 	// This maximum prevents from excessive memory allocation.
 	// The value is set to maximum transmission unit (MTU), as the header of a network packet
-	// may not exceed the MTU.
+	// may not exceed the MTU. It's the default of DecoderOptions.MaximumHeaderLength.
 	MaximumHeaderLength = 1500
+
+	// MaximumFrameLength defines the maximum length acceptable for datagrams
+	// read by a Decoder, framed or not. Like MaximumRecordLength, it's derived from
+	// MAX_PKT_SIZ, as a datagram is sent in a single packet.
+	MaximumFrameLength = 65536
+)
+
+var (
+	ErrUnsupportedDatagramVersion = errors.New("sflow: unsupported datagram version")
+
+	ErrLengthOverrun  = errors.New("sflow: data overruns its length")
+	ErrLengthUnderrun = errors.New("sflow: data underruns its length")
 )
 
-var ErrUnsupportedDatagramVersion = errors.New("sflow: unsupported datagram version")
+// LengthError is returned when the data of a sample or record doesn't
+// match the length preceding it. Err is ErrLengthOverrun if decoding the
+// data needs more than Length bytes, or ErrLengthUnderrun if Trailing
+// bytes are left once it's decoded. Either way, the samples and records
+// following it are still found at the right offset. For bytes left
+// after the last sample of a datagram, DataFormat is 0.
+type LengthError struct {
+	Err        error
+	DataFormat DataFormat
+	Length     int
+	Trailing   int
+}
+
+func (e *LengthError) Error() string {
+	if e.Err == ErrLengthUnderrun && e.DataFormat == 0 {
+		return fmt.Sprintf("sflow: %d trailing bytes in datagram of length %d",
+			e.Trailing, e.Length)
+	}
+
+	if e.Err == ErrLengthUnderrun {
+		return fmt.Sprintf("sflow: %d trailing bytes in %v data of length %d",
+			e.Trailing, e.DataFormat, e.Length)
+	}
+
+	return fmt.Sprintf("sflow: %v data overruns its length of %d",
+		e.DataFormat, e.Length)
+}
+
+func (e *LengthError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned when a datagram fails to decode. It locates
+// the sample or record that failed, and wraps the cause, e.g. a
+// *LengthError or io.ErrUnexpectedEOF for a truncated datagram.
+type DecodeError struct {
+	// Offset is the offset in the datagram of the sample or record
+	// that failed to decode, or of the data that's missing.
+	Offset int
+
+	// Sample and Record are the indexes of the sample that failed
+	// to decode, and of the record in that sample, or -1.
+	Sample int
+	Record int
+
+	// DataFormat is the data format of the record that failed
+	// to decode, or else of the sample.
+	DataFormat DataFormat
+
+	// Agent is the address of the agent that sent the datagram,
+	// or nil if the datagram failed to decode before it.
+	Agent net.IP
+
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	b := &strings.Builder{}
+
+	b.WriteString("sflow: datagram")
+	if e.Agent != nil {
+		fmt.Fprintf(b, " from %v", e.Agent)
+	}
+
+	if e.Sample >= 0 {
+		fmt.Fprintf(b, ", sample %d", e.Sample)
+	}
+
+	if e.Record >= 0 {
+		fmt.Fprintf(b, ", record %d", e.Record)
+	}
+
+	if e.Sample >= 0 || e.Record >= 0 {
+		fmt.Fprintf(b, " (%v)", e.DataFormat)
+	}
+
+	fmt.Fprintf(b, " at offset %d: %v", e.Offset, e.Err)
+
+	return b.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// newDecodeError returns err, which occurred decoding the sample
+// at the given index and offset, as a *DecodeError. If err is a
+// *DecodeError for a record of the sample, it's completed.
+func newDecodeError(err error, offset, sample int, dataFormat DataFormat, agent net.IP) *DecodeError {
+	// The agent address may reference a buffer that's reused.
+	agent = append(net.IP(nil), agent...)
+
+	if e, ok := err.(*DecodeError); ok {
+		// The offset of the record is from the sample data,
+		// which follows the format and length of the sample.
+		e.Offset += offset + 2*4
+		e.Sample = sample
+		e.Agent = agent
+		return e
+	}
+
+	return &DecodeError{
+		Offset:     offset,
+		Sample:     sample,
+		Record:     -1,
+		DataFormat: dataFormat,
+		Agent:      agent,
+		Err:        err,
+	}
+}
 
 type Decoder struct {
-	reader io.ReadSeeker
+	reader  io.Reader
+	framed  bool
+	options DecoderOptions
 }
 
-func NewDecoder(r io.ReadSeeker) *Decoder {
+// NewDecoder returns a decoder that reads consecutive datagrams from r.
+// As datagrams don't include their length, each is read as it's parsed,
+// up to its last sample, and up to MaximumFrameLength bytes.
+//
+// r must be a stream. A packet conn such as *net.UDPConn must not be
+// passed directly, as each read drops the rest of the packet it reads
+// from. Decode its packets with DecodeBytes instead.
+func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{
 		reader: r,
 	}
 }
 
-func (d *Decoder) Use(r io.ReadSeeker) {
+// NewFramedDecoder returns a decoder that reads datagrams from r, each
+// preceded by its length as a 32-bit big-endian number, as written by
+// Encoder.EncodeFramed. Framing keeps the stream in sync past datagrams
+// that fail to decode, e.g. when relaying datagrams over TCP.
+func NewFramedDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		reader: r,
+		framed: true,
+	}
+}
+
+func (d *Decoder) Use(r io.Reader) {
 	d.reader = r
 }
 
+// Decode reads and decodes the next datagram from the reader.
 func (d *Decoder) Decode() (*Datagram, error) {
-	// Decode headers first
+	b, err := d.read(nil)
+	if err != nil {
+		return nil, err
+	}
+
 	dgram := &Datagram{}
-	var err error
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.Version)
+	err = decodeInto(b, dgram, &d.options, false)
 	if err != nil {
 		return nil, err
 	}
 
-	if dgram.Version != 5 {
-		return nil, ErrUnsupportedDatagramVersion
+	return dgram, nil
+}
+
+// DecodeInto reads and decodes the next datagram from the reader
+// into dst. The samples, records and buffer of the datagram previously
+// decoded into dst are decoded into again, so that once dst has held
+// datagrams of the same shape, decoding doesn't allocate, except for
+// the strings and variable-length arrays of some records.
+//
+// As a record value allocates when it's stored in a Record, records
+// are held by dst as pointers, e.g. *HostDiskCounters rather than
+// HostDiskCounters. RecordsOf yields them as values.
+//
+// Because of that, the samples of dst and their records, including
+// byte fields such as RawPacketFlow.Header, must not be used once
+// dst is decoded into again.
+func (d *Decoder) DecodeInto(dst *Datagram) error {
+	b, err := d.read(dst.buf[:0])
+	if err != nil {
+		return err
 	}
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.IpVersion)
+	dst.buf = b
+
+	return decodeInto(b, dst, &d.options, true)
+}
+
+var datagramPool = sync.Pool{
+	New: func() interface{} {
+		return &Datagram{}
+	},
+}
+
+// DecodePooled reads and decodes the next datagram from the reader
+// like DecodeInto, into a datagram taken from a pool rather than
+// allocated. Once the datagram is no longer used, ReleaseDatagram
+// returns it to the pool, so that it's decoded into again.
+func (d *Decoder) DecodePooled() (*Datagram, error) {
+	dgram := datagramPool.Get().(*Datagram)
+
+	err := d.DecodeInto(dgram)
 	if err != nil {
+		datagramPool.Put(dgram)
 		return nil, err
 	}
 
-	ipLen := 4
-	if dgram.IpVersion == 2 {
-		ipLen = 16
+	return dgram, nil
+}
+
+// ReleaseDatagram returns dgram, which was returned by DecodePooled,
+// to the pool. The datagram must not be used afterwards.
+func ReleaseDatagram(dgram *Datagram) {
+	datagramPool.Put(dgram)
+}
+
+// read reads the next datagram from the reader and appends it to buf.
+func (d *Decoder) read(buf []byte) ([]byte, error) {
+	if d.framed {
+		return d.readFrame(buf)
 	}
 
-	ipBuf := make([]byte, ipLen)
-	_, err = d.reader.Read(ipBuf)
+	// The datagram doesn't include its length, so we read the header
+	// and then each sample.
+	start := len(buf)
+
+	buf, err := readFull(d.reader, buf, 2*4)
 	if err != nil {
+		// io.EOF is returned as is if there are no more datagrams.
 		return nil, err
 	}
 
-	dgram.IpAddress = ipBuf
+	var dgram Datagram
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.SubAgentId)
+	ipLen, err := decodeVersions(buf[start:], &dgram)
 	if err != nil {
-		return nil, err
+		return nil, newDecodeError(err, 0, -1, 0, nil)
 	}
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.SequenceNumber)
+	buf, err = readFull(d.reader, buf, ipLen+4*4)
 	if err != nil {
-		return nil, err
+		return nil, newDecodeError(unexpectedEOF(err), 2*4, -1, 0, nil)
+	}
+
+	decodeAgent(buf[start+2*4:], ipLen, &dgram)
+
+	for i := 0; i < int(dgram.NumSamples); i++ {
+		offset := len(buf) - start
+
+		buf, err = readFull(d.reader, buf, 2*4)
+		if err != nil {
+			return nil, newDecodeError(unexpectedEOF(err), offset, i, 0, dgram.IpAddress)
+		}
+
+		format := DataFormat(binary.BigEndian.Uint32(buf[len(buf)-2*4:]))
+		length := binary.BigEndian.Uint32(buf[len(buf)-4:])
+
+		if maximum := d.options.maximumRecordLength(); length > maximum {
+			err = fmt.Errorf("sflow: sample length more than %d: %d",
+				maximum, length)
+			return nil, newDecodeError(err, offset, i, format, dgram.IpAddress)
+		}
+
+		// Datagrams are bounded like frames, so that a bogus
+		// number of samples can't make buf grow indefinitely.
+		if total := len(buf) - start + int(length); total > MaximumFrameLength {
+			err = fmt.Errorf("sflow: datagram length more than %d: %d",
+				MaximumFrameLength, total)
+			return nil, newDecodeError(err, offset, i, format, dgram.IpAddress)
+		}
+
+		buf, err = readFull(d.reader, buf, int(length))
+		if err != nil {
+			return nil, newDecodeError(unexpectedEOF(err), offset, i, format, dgram.IpAddress)
+		}
 	}
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.Uptime)
+	return buf, nil
+}
+
+// readFrame reads the next length-prefixed datagram from the reader
+// and appends it to buf, without the length.
+func (d *Decoder) readFrame(buf []byte) ([]byte, error) {
+	start := len(buf)
+
+	buf, err := readFull(d.reader, buf, 4)
 	if err != nil {
 		return nil, err
 	}
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.NumSamples)
+	length := binary.BigEndian.Uint32(buf[start:])
+	buf = buf[:start]
+
+	if length > MaximumFrameLength {
+		return nil, fmt.Errorf("sflow: frame length more than %d: %d",
+			MaximumFrameLength, length)
+	}
+
+	buf, err = readFull(d.reader, buf, int(length))
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return buf, nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF if err is io.EOF,
+// for reads in the middle of a datagram.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// readFull reads exactly n bytes from r and appends them to b.
+func readFull(r io.Reader, b []byte, n int) ([]byte, error) {
+	b = slices.Grow(b, n)
+
+	_, err := io.ReadFull(r, b[len(b):len(b)+n])
 	if err != nil {
 		return nil, err
 	}
 
-	for i := dgram.NumSamples; i > 0; i-- {
-		sample, err := decodeSample(d.reader)
+	return b[:len(b)+n], nil
+}
+
+// DecodeBytes decodes the datagram in b, e.g. the payload of a UDP
+// packet. The decoded datagram doesn't reference b.
+func DecodeBytes(b []byte) (*Datagram, error) {
+	return defaultDecoderOptions.DecodeBytes(b)
+}
+
+// DecodeBytesNoCopy decodes the datagram in b like DecodeBytes, but
+// without copying b. Byte fields of the decoded datagram, such as
+// RawPacketFlow.Header, reference b, so b must not be modified
+// while the datagram is in use.
+func DecodeBytesNoCopy(b []byte) (*Datagram, error) {
+	return defaultDecoderOptions.DecodeBytesNoCopy(b)
+}
+
+// DecodeBytesInto decodes the datagram in b into dst, reusing the
+// samples and records of dst like Decoder.DecodeInto. As with DecodeBytesNoCopy,
+// byte fields of dst reference b.
+func DecodeBytesInto(b []byte, dst *Datagram) error {
+	return defaultDecoderOptions.DecodeBytesInto(b, dst)
+}
+
+// decodeInto decodes the datagram in b into dgram with the given
+// options. If reuse is true, the samples of dgram are decoded into.
+func decodeInto(b []byte, dgram *Datagram, options *DecoderOptions, reuse bool) error {
+	size := len(b)
+
+	if len(b) < 2*4 {
+		return newDecodeError(io.ErrUnexpectedEOF, 0, -1, 0, nil)
+	}
+
+	ipLen, err := decodeVersions(b, dgram)
+	if err != nil {
+		return newDecodeError(err, 0, -1, 0, nil)
+	}
+
+	b = b[2*4:]
+	if len(b) < ipLen+4*4 {
+		return newDecodeError(io.ErrUnexpectedEOF, 2*4, -1, 0, nil)
+	}
+
+	decodeAgent(b, ipLen, dgram)
+	b = b[ipLen+4*4:]
+
+	st := &decodeState{
+		options: options,
+		errors:  dgram.Errors[:0],
+	}
+
+	var prevSamples []Sample
+
+	if reuse {
+		prevSamples = dgram.Samples
+
+		dgram.records.reset()
+		st.records = &dgram.records
+	}
+
+	samples := prevSamples[:0]
+	if samples == nil {
+		// Each sample takes at least 8 bytes, which bounds
+		// the allocation if NumSamples is bogus.
+		samples = make([]Sample, 0, min(int(dgram.NumSamples), len(b)/(2*4)))
+	}
+
+	for i := 0; i < int(dgram.NumSamples); i++ {
+		offset := size - len(b)
+
+		var format DataFormat
+		var length uint32
+
+		if len(b) >= 2*4 {
+			format = DataFormat(binary.BigEndian.Uint32(b))
+			length = binary.BigEndian.Uint32(b[4:])
+		}
+
+		if len(b) < 2*4 || int(length) > len(b)-2*4 {
+			err = newDecodeError(io.ErrUnexpectedEOF, offset, i, format, dgram.IpAddress)
+			if !st.lenient() {
+				return err
+			}
+
+			// The samples following it can't be found, and
+			// the rest of the datagram is the truncated sample.
+			st.errors = append(st.errors, err)
+			b = nil
+			break
+		}
+
+		b = b[2*4:]
+
+		if !st.decodesSample(format) {
+			b = b[length:]
+			continue
+		}
+
+		// Samples are reused in order, as skipped samples
+		// don't take a place in dgram.Samples.
+		var prev Sample
+		if n := len(samples); n < len(prevSamples) {
+			prev = prevSamples[n]
+		}
+
+		first := len(st.errors)
+
+		sample, err := decodeSample(format, b[:length:length], prev, st)
+
+		// Complete the errors of the records skipped in lenient mode.
+		for _, recordErr := range st.errors[first:] {
+			newDecodeError(recordErr, offset, i, format, dgram.IpAddress)
+		}
+
 		if err != nil {
-			return nil, err
+			err = newDecodeError(err, offset, i, format, dgram.IpAddress)
+			if !st.lenient() {
+				return err
+			}
+
+			st.errors = append(st.errors, err)
 		}
 
-		dgram.Samples = append(dgram.Samples, sample)
+		if sample != nil {
+			samples = append(samples, sample)
+		}
+
+		b = b[length:]
 	}
 
-	return dgram, nil
+	if len(b) > 0 {
+		err = &LengthError{
+			Err:      ErrLengthUnderrun,
+			Length:   size,
+			Trailing: len(b),
+		}
+
+		err = newDecodeError(err, size-len(b), -1, 0, dgram.IpAddress)
+		if !st.lenient() {
+			return err
+		}
+
+		st.errors = append(st.errors, err)
+	}
+
+	dgram.Samples = samples
+	dgram.Errors = st.errors
+
+	return nil
+}
+
+// decodeVersions decodes the datagram and IP versions
+// from b, and returns the length of the agent address.
+func decodeVersions(b []byte, dgram *Datagram) (int, error) {
+	dgram.Version = binary.BigEndian.Uint32(b)
+	if dgram.Version != 5 {
+		return 0, ErrUnsupportedDatagramVersion
+	}
+
+	dgram.IpVersion = AddressType(binary.BigEndian.Uint32(b[4:]))
+
+	ipLen, ok := dgram.IpVersion.length()
+	if !ok {
+		return 0, &AddressTypeError{AddressType: dgram.IpVersion}
+	}
+
+	return ipLen, nil
+}
+
+// decodeAgent decodes the agent address and the fields
+// following it, up to the number of samples, from b.
+func decodeAgent(b []byte, ipLen int, dgram *Datagram) {
+	dgram.IpAddress = nil
+	if ipLen > 0 {
+		dgram.IpAddress = net.IP(b[:ipLen:ipLen])
+	}
+
+	b = b[ipLen:]
+
+	dgram.SubAgentId = binary.BigEndian.Uint32(b)
+	dgram.SequenceNumber = binary.BigEndian.Uint32(b[4:])
+	dgram.Uptime = binary.BigEndian.Uint32(b[8:])
+	dgram.NumSamples = binary.BigEndian.Uint32(b[12:])
 }
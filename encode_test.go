@@ -2,7 +2,9 @@ package sflow
 
 import (
 	"bytes"
+	"net"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -92,3 +94,180 @@ func TestDecodeAndEncodeGenericEthernetCounterDatagram(t *testing.T) {
 		t.Errorf("expected\n%#v, got\n%#v", expectedGenericInterfaceCounters, genericInterfaceCounters)
 	}
 }
+
+func TestEncodeAndDecodeUnknownSample(t *testing.T) {
+	unknown := &UnknownSample{
+		DataFormat: NewDataFormat(9999, 1),
+		Data:       []byte{0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3},
+	}
+	counters := &CounterSample{
+		SequenceNum: 7,
+		Records:     []Record{FanCounters{Total: 4, Failed: 1, Speed: 80}},
+	}
+
+	buf := &bytes.Buffer{}
+	enc := NewEncoder(net.ParseIP("192.0.2.1"), 0, 1)
+
+	err := enc.Encode(buf, []Sample{unknown, counters})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := append([]byte(nil), buf.Bytes()...)
+
+	d := NewDecoder(bytes.NewReader(buf.Bytes()))
+
+	dgram, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(dgram.Samples))
+	}
+
+	if !reflect.DeepEqual(dgram.Samples[0], unknown) {
+		t.Errorf("expected\n%v, got\n%v", unknown, dgram.Samples[0])
+	}
+
+	if _, ok := dgram.Samples[1].(*CounterSample); !ok {
+		t.Fatalf("expected a CounterSample, got %T", dgram.Samples[1])
+	}
+
+	buf.Reset()
+	enc = NewEncoder(dgram.IpAddress, dgram.SubAgentId, dgram.SequenceNumber)
+
+	err = enc.Encode(buf, dgram.Samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Errorf("expected re-encoded datagram\n%x, got\n%x", encoded, buf.Bytes())
+	}
+}
+
+func TestDecodeAndEncodeDatagramBytes(t *testing.T) {
+	dumps := []string{
+		"_test/counter_sample.dump",
+		"_test/flow_sample.dump",
+		"_test/event_discarded_packet.dump",
+	}
+
+	for _, dump := range dumps {
+		b, err := os.ReadFile(dump)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d := NewDecoder(bytes.NewReader(b))
+
+		dgram, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(dgram.IpAddress, dgram.SubAgentId, dgram.SequenceNumber)
+		enc.Uptime = dgram.Uptime
+
+		err = enc.Encode(buf, dgram.Samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(buf.Bytes(), b) {
+			t.Errorf("%s: expected re-encoded datagram\n%x, got\n%x", dump, b, buf.Bytes())
+		}
+	}
+}
+
+func TestEncodeFramed(t *testing.T) {
+	b, err := os.ReadFile("_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgram, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	enc := NewEncoder(dgram.IpAddress, dgram.SubAgentId, dgram.SequenceNumber)
+	enc.Uptime = dgram.Uptime
+
+	for i := 0; i < 2; i++ {
+		err = enc.EncodeFramed(buf, dgram.Samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := NewFramedDecoder(buf)
+
+	for i := uint32(0); i < 2; i++ {
+		decoded, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.SequenceNumber != dgram.SequenceNumber+i {
+			t.Errorf("expected sequence number %d, got %d", dgram.SequenceNumber+i, decoded.SequenceNumber)
+		}
+
+		if !reflect.DeepEqual(decoded.Samples, dgram.Samples) {
+			t.Errorf("expected\n%v, got\n%v", dgram.Samples, decoded.Samples)
+		}
+	}
+}
+
+func TestEncodeAndDecodeAgentAddress(t *testing.T) {
+	samples := []Sample{&CounterSample{Records: []Record{HumidityCounters{Relative: 40}}}}
+
+	cases := []struct {
+		ip          net.IP
+		addressType AddressType
+		length      int
+	}{
+		{nil, AddressTypeUnknown, 0},
+		{net.IPv4(10, 0, 0, 1), AddressTypeIPv4, 4},
+		{net.ParseIP("2001:db8::1"), AddressTypeIPv6, 16},
+	}
+
+	for _, c := range cases {
+		buf := &bytes.Buffer{}
+
+		err := NewEncoder(c.ip, 0, 1).Encode(buf, samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The address follows the version and address type.
+		if buf.Len() != 2*4+c.length+4*4+2*4+3*4+2*4+4 {
+			t.Errorf("%v: expected an address of %d bytes, got a datagram of %d bytes", c.ip, c.length, buf.Len())
+		}
+
+		dgram, err := DecodeBytes(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dgram.IpVersion != c.addressType {
+			t.Errorf("%v: expected address type %v, got %v", c.ip, c.addressType, dgram.IpVersion)
+		}
+
+		if !dgram.IpAddress.Equal(c.ip) || len(dgram.IpAddress) != c.length {
+			t.Errorf("expected address %v, got %v", c.ip, dgram.IpAddress)
+		}
+
+		if len(dgram.Samples) != 1 {
+			t.Errorf("%v: expected 1 sample, got %d", c.ip, len(dgram.Samples))
+		}
+	}
+
+	err := NewEncoder(net.IP{1, 2, 3}, 0, 1).Encode(&bytes.Buffer{}, samples)
+	if err != ErrInvalidAddress {
+		t.Errorf("expected %v, got %v", ErrInvalidAddress, err)
+	}
+}
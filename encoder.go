@@ -1,6 +1,7 @@
 package sflow
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"io"
@@ -18,7 +19,8 @@ type Encoder struct {
 	Uptime      uint32
 }
 
-// NewEncoder returns a new sFlow encoder.
+// NewEncoder returns a new sFlow encoder. The agent address of the
+// datagrams is source, or of type AddressTypeUnknown if source is nil.
 func NewEncoder(source net.IP, subAgentId uint32, initialSequenceNumber uint32) *Encoder {
 	return &Encoder{
 		ip:          source,
@@ -42,15 +44,14 @@ func (e *Encoder) Encode(w io.Writer, samples []Sample) error {
 		return err
 	}
 
-	// Check IP version
-	ipVersion := uint32(1)
-	ipBytes := []byte(e.ip.To4())
-	if ipBytes == nil {
-		ipVersion = 2
-		ipBytes = []byte(e.ip.To16())
+	// The agent address is a union of its type and bytes,
+	// which are left out if it's unknown.
+	addressType, ipBytes, err := addressOf(e.ip)
+	if err != nil {
+		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, ipVersion)
+	err = binary.Write(w, binary.BigEndian, uint32(addressType))
 	if err != nil {
 		return err
 	}
@@ -91,3 +92,24 @@ func (e *Encoder) Encode(w io.Writer, samples []Sample) error {
 
 	return nil
 }
+
+// EncodeFramed encodes a datagram like Encode, and writes it to w
+// preceded by its length as a 32-bit big-endian number, so that
+// datagrams can be sent over a stream and read by a decoder returned
+// by NewFramedDecoder.
+func (e *Encoder) EncodeFramed(w io.Writer, samples []Sample) error {
+	buf := &bytes.Buffer{}
+
+	err := e.Encode(buf, samples)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint32(buf.Len()))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, buf)
+	return err
+}
@@ -9,8 +9,7 @@ import (
 
 type EventDiscardedPacket struct {
 	SequenceNum uint32
-	DsClass     uint32
-	DsIndex     uint32
+	Source      DataSource
 	Drops       uint32
 	Input       uint32
 	Output      uint32
@@ -26,7 +25,7 @@ func (s EventDiscardedPacket) String() string {
 }
 
 // SampleType returns the type of sFlow sample.
-func (s *EventDiscardedPacket) SampleType() int {
+func (s *EventDiscardedPacket) SampleType() DataFormat {
 	return TypeEventDiscardedPacket
 }
 
@@ -34,91 +33,61 @@ func (s *EventDiscardedPacket) GetRecords() []Record {
 	return s.Records
 }
 
-func decodEventDiscardedPacket(r io.ReadSeeker) (Sample, error) {
-	s := &EventDiscardedPacket{}
+// Record returns the i-th record, decoding it if it's a RawRecord.
+func (s *EventDiscardedPacket) Record(i int) (Record, error) {
+	return flowRecordFormats.record(s.Records, i)
+}
 
-	var err error
+// Validate checks the sample and its records, and returns a
+// *ValidationError listing every violation found.
+func (s *EventDiscardedPacket) Validate() error {
+	var v violations
 
-	err = binary.Read(r, binary.BigEndian, &s.SequenceNum)
-	if err != nil {
-		return nil, err
-	}
+	v.addRecords(s)
 
-	err = binary.Read(r, binary.BigEndian, &s.DsClass)
-	if err != nil {
-		return nil, err
-	}
+	return v.err()
+}
 
-	err = binary.Read(r, binary.BigEndian, &s.DsIndex)
-	if err != nil {
-		return nil, err
+func decodEventDiscardedPacket(b []byte, prev Sample, st *decodeState) (Sample, int, error) {
+	s, ok := prev.(*EventDiscardedPacket)
+	if !ok || s == nil {
+		s = &EventDiscardedPacket{}
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.Drops)
-	if err != nil {
-		return nil, err
+	if len(b) < 8*4 {
+		return nil, 0, ErrLengthOverrun
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.Input)
-	if err != nil {
-		return nil, err
-	}
+	var sourceType uint32
 
-	err = binary.Read(r, binary.BigEndian, &s.Output)
-	if err != nil {
-		return nil, err
+	// The source is in the expanded format, with the type
+	// and index in separate 32-bit fields.
+	fields := []interface{}{
+		&s.SequenceNum,
+		&sourceType,
+		&s.Source.Index,
+		&s.Drops,
+		&s.Input,
+		&s.Output,
+		&s.Reason,
+		&s.numRecords,
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.Reason)
+	_, err := readFields(b, fields)
 	if err != nil {
-		return nil, fmt.Errorf("read reson %v", err)
+		return nil, 0, err
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.numRecords)
+	s.Source.Type = DataSourceType(sourceType)
+
+	records, n, err := flowRecordFormats.decodeRecords(b, 8*4, s.numRecords, s.Records, st)
 	if err != nil {
-		return nil, fmt.Errorf("read numRecords %v", err)
+		return nil, 0, err
 	}
 
-	for i := uint32(0); i < s.numRecords; i++ {
-		format, length := uint32(0), uint32(0)
+	s.Records = records
 
-		err = binary.Read(r, binary.BigEndian, &format)
-		if err != nil {
-			return nil, fmt.Errorf("read record format %d %v", i, err)
-		}
-
-		err = binary.Read(r, binary.BigEndian, &length)
-		if err != nil {
-			return nil, fmt.Errorf("read record length %d %v", i, err)
-		}
-
-		var rec Record
-
-		switch format {
-		case TypeRawPacketFlowRecord:
-			rec, err = decodeRawPacketFlow(r)
-			if err != nil {
-				return nil, fmt.Errorf("read record flow %d %v", i, err)
-			}
-		case TypeExtendedSwitchFlowRecord:
-			rec, err = decodedExtendedSwitchFlow(r)
-			if err != nil {
-				return nil, fmt.Errorf("read record switch flow %d %v", i, err)
-			}
-
-		default:
-			_, err := r.Seek(int64(length), 1)
-			if err != nil {
-				return nil, fmt.Errorf("read record seek %d %v", i, err)
-			}
-
-			continue
-		}
-
-		s.Records = append(s.Records, rec)
-	}
-
-	return s, nil
+	return s, n, nil
 }
 
 func (s *EventDiscardedPacket) encode(w io.Writer) error {
@@ -128,7 +97,7 @@ func (s *EventDiscardedPacket) encode(w io.Writer) error {
 	buf := &bytes.Buffer{}
 
 	for _, rec := range s.Records {
-		err = rec.encode(buf)
+		err = flowRecordFormats.encode(buf, rec)
 		if err != nil {
 			return ErrEncodingRecord
 		}
@@ -152,11 +121,7 @@ func (s *EventDiscardedPacket) encode(w io.Writer) error {
 	if err != nil {
 		return err
 	}
-	err = binary.Write(w, binary.BigEndian, s.DsClass)
-	if err != nil {
-		return err
-	}
-	err = binary.Write(w, binary.BigEndian, s.DsIndex)
+	err = binary.Write(w, binary.BigEndian, s.Source)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,165 @@
+package sflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ExpandedFlowSample is a flow sample whose data source and
+// interfaces don't fit in the compact encoding of FlowSample.
+type ExpandedFlowSample struct {
+	SequenceNum  uint32
+	Source       DataSource
+	SamplingRate uint32
+	SamplePool   uint32
+	Drops        uint32
+	Input        Interface
+	Output       Interface
+	numRecords   uint32
+	Records      []Record
+}
+
+func (s ExpandedFlowSample) String() string {
+	type X ExpandedFlowSample
+	x := X(s)
+	return fmt.Sprintf("ExpandedFlowSample: %+v", x)
+}
+
+// SampleType returns the type of sFlow sample.
+func (s *ExpandedFlowSample) SampleType() DataFormat {
+	return TypeExpandedFlowSample
+}
+
+func (s *ExpandedFlowSample) GetRecords() []Record {
+	return s.Records
+}
+
+// Record returns the i-th record, decoding it if it's a RawRecord.
+func (s *ExpandedFlowSample) Record(i int) (Record, error) {
+	return flowRecordFormats.record(s.Records, i)
+}
+
+// Validate checks the sample and its records, and returns a
+// *ValidationError listing every violation found.
+func (s *ExpandedFlowSample) Validate() error {
+	var v violations
+
+	if s.SamplingRate == 0 {
+		v.add("sampling rate is zero")
+	}
+
+	v.addRecords(s)
+
+	return v.err()
+}
+
+func decodeExpandedFlowSample(b []byte, prev Sample, st *decodeState) (Sample, int, error) {
+	s, ok := prev.(*ExpandedFlowSample)
+	if !ok || s == nil {
+		s = &ExpandedFlowSample{}
+	}
+
+	if len(b) < 11*4 {
+		return nil, 0, ErrLengthOverrun
+	}
+
+	var sourceType, inputFormat, outputFormat uint32
+
+	// Each interface is a 32-bit format followed by a 32-bit value.
+	fields := []interface{}{
+		&s.SequenceNum,
+		&sourceType,
+		&s.Source.Index,
+		&s.SamplingRate,
+		&s.SamplePool,
+		&s.Drops,
+		&inputFormat,
+		&s.Input.value,
+		&outputFormat,
+		&s.Output.value,
+		&s.numRecords,
+	}
+
+	_, err := readFields(b, fields)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.Source.Type = DataSourceType(sourceType)
+	s.Input.format = InterfaceFormat(inputFormat)
+	s.Output.format = InterfaceFormat(outputFormat)
+
+	records, n, err := flowRecordFormats.decodeRecords(b, 11*4, s.numRecords, s.Records, st)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.Records = records
+
+	return s, n, nil
+}
+
+func (s *ExpandedFlowSample) encode(w io.Writer) error {
+	var err error
+
+	// We first need to encode the records.
+	buf := &bytes.Buffer{}
+
+	for _, rec := range s.Records {
+		err = flowRecordFormats.encode(buf, rec)
+		if err != nil {
+			return ErrEncodingRecord
+		}
+	}
+
+	// Fields
+	encodedSampleSize := uint32(4 * 11)
+
+	// Encoded records
+	encodedSampleSize += uint32(buf.Len())
+
+	err = binary.Write(w, binary.BigEndian, uint32(s.SampleType()))
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, encodedSampleSize)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, s.SequenceNum)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, s.Source)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, s.SamplingRate)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, s.SamplePool)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, s.Drops)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, [4]uint32{
+		uint32(s.Input.format), s.Input.value,
+		uint32(s.Output.format), s.Output.value,
+	})
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, uint32(len(s.Records)))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, buf)
+	return err
+}
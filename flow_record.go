@@ -35,135 +35,90 @@ func (f ExtendedSwitchFlow) String() string {
 	return fmt.Sprintf("ExtendedSwitchFlow: %+v", x)
 }
 
+func init() {
+	registerRecord(flowRecordFormats, TypeRawPacketFlowRecord, decodeRawPacketFlow, encodeRawPacketFlow)
+	registerRecord(flowRecordFormats, TypeExtendedSwitchFlowRecord, decodedExtendedSwitchFlow, encodeFixedRecord)
+}
+
 // RecordType returns the type of flow record.
-func (f RawPacketFlow) RecordType() int {
+func (f RawPacketFlow) RecordType() DataFormat {
 	return TypeRawPacketFlowRecord
 }
 
-func decodeRawPacketFlow(r io.Reader) (RawPacketFlow, error) {
-	f := RawPacketFlow{}
-
-	var err error
-
-	err = binary.Read(r, binary.BigEndian, &f.Protocol)
-	if err != nil {
-		return f, err
+func decodeRawPacketFlow(b []byte, f *RawPacketFlow) (int, error) {
+	if len(b) < 4*4 {
+		return 0, ErrDecodingRecord
 	}
 
-	err = binary.Read(r, binary.BigEndian, &f.FrameLength)
-	if err != nil {
-		return f, err
+	fields := []interface{}{
+		&f.Protocol,
+		&f.FrameLength,
+		&f.Stripped,
+		&f.HeaderSize,
 	}
 
-	err = binary.Read(r, binary.BigEndian, &f.Stripped)
+	_, err := readFields(b, fields)
 	if err != nil {
-		return f, err
-	}
-
-	err = binary.Read(r, binary.BigEndian, &f.HeaderSize)
-	if err != nil {
-		return f, err
-	}
-	if f.HeaderSize > MaximumHeaderLength {
-		return f, fmt.Errorf("sflow: header length more than %d: %d",
-			MaximumHeaderLength, f.HeaderSize)
-	}
-
-	padding := (4 - int(f.HeaderSize)) % 4
-	if padding < 0 {
-		padding += 4
+		return 0, err
 	}
 
-	f.Header = make([]byte, f.HeaderSize+uint32(padding))
+	// The header is padded to a multiple of 4 bytes,
+	// but len(Header) should still be HeaderSize.
+	padded := (int(f.HeaderSize) + 3) &^ 3
 
-	_, err = r.Read(f.Header)
-	if err != nil {
-		return f, err
+	b = b[4*4:]
+	if padded > len(b) {
+		return 0, ErrDecodingRecord
 	}
 
-	// We need to consume the padded length,
-	// but len(Header) should still be HeaderSize.
-	f.Header = f.Header[:f.HeaderSize]
+	f.Header = b[:f.HeaderSize:f.HeaderSize]
 
-	return f, err
+	return 4*4 + padded, nil
 }
 
-func (f RawPacketFlow) encode(w io.Writer) error {
-	var err error
+func encodeRawPacketFlow(w io.Writer, rec Record) error {
+	f, ok := rec.(RawPacketFlow)
+	if !ok {
+		return ErrEncodingRecord
+	}
 
-	err = binary.Write(w, binary.BigEndian, uint32(f.RecordType()))
+	err := binary.Write(w, binary.BigEndian, []uint32{
+		f.Protocol,
+		f.FrameLength,
+		f.Stripped,
+		f.HeaderSize,
+	})
 	if err != nil {
 		return err
 	}
 
-	// We need to calculate encoded size of the record.
-	encodedRecordLength := uint32(4 * 4) // 4 32-bit records
-
-	// Add the length of the header padded to a multiple of 4 bytes.
+	// The header is padded to a multiple of 4 bytes.
 	padding := (4 - int(f.HeaderSize)) % 4
 	if padding < 0 {
 		padding += 4
 	}
 
-	encodedRecordLength += f.HeaderSize + uint32(padding)
-
-	err = binary.Write(w, binary.BigEndian, encodedRecordLength)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, f.Protocol)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, f.FrameLength)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, f.Stripped)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, f.HeaderSize)
-	if err != nil {
-		return err
-	}
-
 	_, err = w.Write(append(f.Header, make([]byte, padding)...))
 
 	return err
 }
 
 // RecordType returns the type of flow record.
-func (f ExtendedSwitchFlow) RecordType() int {
+func (f ExtendedSwitchFlow) RecordType() DataFormat {
 	return TypeExtendedSwitchFlowRecord
 }
 
-func decodedExtendedSwitchFlow(r io.Reader) (ExtendedSwitchFlow, error) {
-	f := ExtendedSwitchFlow{}
-
-	err := binary.Read(r, binary.BigEndian, &f)
-
-	return f, err
-}
-
-func (f ExtendedSwitchFlow) encode(w io.Writer) error {
-	var err error
-
-	err = binary.Write(w, binary.BigEndian, uint32(f.RecordType()))
-	if err != nil {
-		return err
+func decodedExtendedSwitchFlow(b []byte, f *ExtendedSwitchFlow) (int, error) {
+	if len(b) < 4*4 {
+		return 0, ErrDecodingRecord
 	}
 
-	encodedRecordLength := uint32(4 * 4) // 4 32-bit records
-
-	err = binary.Write(w, binary.BigEndian, encodedRecordLength)
-	if err != nil {
-		return err
+	fields := []interface{}{
+		&f.SourceVlan,
+		&f.SourcePriority,
+		&f.DestinationVlan,
+		&f.DestinationPriority,
 	}
 
-	return binary.Write(w, binary.BigEndian, f)
+	return readFields(b, fields)
 }
@@ -36,7 +36,7 @@ func TestEncodeDecodeRawPacketFlowRecord(t *testing.T) {
 
 	b := &bytes.Buffer{}
 
-	err := rec.encode(b)
+	err := flowRecordFormats.encode(b, rec)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -49,7 +49,7 @@ func TestEncodeDecodeRawPacketFlowRecord(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	decoded, err := decodeRawPacketFlow(b)
+	decoded, _, err := flowRecordFormats.decode(TypeRawPacketFlowRecord, b.Bytes())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3,42 +3,40 @@ package sflow
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
 )
 
 const (
-	TypeRawPacketFlowRecord     = 1
-	TypeEthernetFrameFlowRecord = 2
-	TypeIpv4FlowRecord          = 3
-	TypeIpv6FlowRecord          = 4
-
-	TypeExtendedSwitchFlowRecord     = 1001
-	TypeExtendedRouterFlowRecord     = 1002
-	TypeExtendedGatewayFlowRecord    = 1003
-	TypeExtendedUserFlowRecord       = 1004
-	TypeExtendedUrlFlowRecord        = 1005
-	TypeExtendedMlpsFlowRecord       = 1006
-	TypeExtendedNatFlowRecord        = 1007
-	TypeExtendedMlpsTunnelFlowRecord = 1008
-	TypeExtendedMlpsVcFlowRecord     = 1009
-	TypeExtendedMlpsFecFlowRecord    = 1010
-	TypeExtendedMlpsLvpFecFlowRecord = 1011
-	TypeExtendedVlanFlowRecord       = 1012
+	TypeRawPacketFlowRecord     DataFormat = 1
+	TypeEthernetFrameFlowRecord DataFormat = 2
+	TypeIpv4FlowRecord          DataFormat = 3
+	TypeIpv6FlowRecord          DataFormat = 4
+
+	TypeExtendedSwitchFlowRecord     DataFormat = 1001
+	TypeExtendedRouterFlowRecord     DataFormat = 1002
+	TypeExtendedGatewayFlowRecord    DataFormat = 1003
+	TypeExtendedUserFlowRecord       DataFormat = 1004
+	TypeExtendedUrlFlowRecord        DataFormat = 1005
+	TypeExtendedMlpsFlowRecord       DataFormat = 1006
+	TypeExtendedNatFlowRecord        DataFormat = 1007
+	TypeExtendedMlpsTunnelFlowRecord DataFormat = 1008
+	TypeExtendedMlpsVcFlowRecord     DataFormat = 1009
+	TypeExtendedMlpsFecFlowRecord    DataFormat = 1010
+	TypeExtendedMlpsLvpFecFlowRecord DataFormat = 1011
+	TypeExtendedVlanFlowRecord       DataFormat = 1012
 )
 
 type FlowSample struct {
-	SequenceNum      uint32
-	SourceIdType     byte
-	SourceIdIndexVal uint32 // NOTE: this is 3 bytes in the datagram
-	SamplingRate     uint32
-	SamplePool       uint32
-	Drops            uint32
-	Input            uint32
-	Output           uint32
-	numRecords       uint32
-	Records          []Record
+	SequenceNum  uint32
+	Source       DataSource
+	SamplingRate uint32
+	SamplePool   uint32
+	Drops        uint32
+	Input        Interface
+	Output       Interface
+	numRecords   uint32
+	Records      []Record
 }
 
 func (s FlowSample) String() string {
@@ -48,7 +46,7 @@ func (s FlowSample) String() string {
 }
 
 // SampleType returns the type of sFlow sample.
-func (s *FlowSample) SampleType() int {
+func (s *FlowSample) SampleType() DataFormat {
 	return TypeFlowSample
 }
 
@@ -56,122 +54,95 @@ func (s *FlowSample) GetRecords() []Record {
 	return s.Records
 }
 
-func decodeFlowSample(r io.ReadSeeker) (Sample, error) {
-	s := &FlowSample{}
+// Record returns the i-th record, decoding it if it's a RawRecord.
+func (s *FlowSample) Record(i int) (Record, error) {
+	return flowRecordFormats.record(s.Records, i)
+}
 
-	var err error
+// Validate checks the sample and its records, and returns a
+// *ValidationError listing every violation found.
+func (s *FlowSample) Validate() error {
+	var v violations
 
-	err = binary.Read(r, binary.BigEndian, &s.SequenceNum)
-	if err != nil {
-		return nil, err
+	if s.SamplingRate == 0 {
+		v.add("sampling rate is zero")
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.SourceIdType)
-	if err != nil {
-		return nil, err
-	}
+	v.addRecords(s)
 
-	var srcIdIndexVal [3]byte
-	n, err := r.Read(srcIdIndexVal[:])
-	if err != nil {
-		return nil, err
+	return v.err()
+}
+
+func decodeFlowSample(b []byte, prev Sample, st *decodeState) (Sample, int, error) {
+	s, ok := prev.(*FlowSample)
+	if !ok || s == nil {
+		s = &FlowSample{}
 	}
 
-	if n != 3 {
-		return nil, errors.New("sflow: counter sample decoding error")
+	if len(b) < 8*4 {
+		return nil, 0, ErrLengthOverrun
 	}
 
-	s.SourceIdIndexVal = uint32(srcIdIndexVal[2]) |
-		uint32(srcIdIndexVal[1])<<8 |
-		uint32(srcIdIndexVal[0])<<16
+	var source, input, output uint32
 
-	err = binary.Read(r, binary.BigEndian, &s.SamplingRate)
-	if err != nil {
-		return nil, err
+	fields := []interface{}{
+		&s.SequenceNum,
+		&source,
+		&s.SamplingRate,
+		&s.SamplePool,
+		&s.Drops,
+		&input,
+		&output,
+		&s.numRecords,
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.SamplePool)
+	_, err := readFields(b, fields)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.Drops)
-	if err != nil {
-		return nil, err
-	}
+	s.Source = decodeDataSource(source)
+	s.Input = decodeInterface(input)
+	s.Output = decodeInterface(output)
 
-	err = binary.Read(r, binary.BigEndian, &s.Input)
+	records, n, err := flowRecordFormats.decodeRecords(b, 8*4, s.numRecords, s.Records, st)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.Output)
+	s.Records = records
+
+	return s, n, nil
+}
+
+func (s *FlowSample) encode(w io.Writer) error {
+	source, err := s.Source.compact()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = binary.Read(r, binary.BigEndian, &s.numRecords)
+	input, err := s.Input.compact()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	for i := uint32(0); i < s.numRecords; i++ {
-		format, length := uint32(0), uint32(0)
-
-		err = binary.Read(r, binary.BigEndian, &format)
-		if err != nil {
-			return nil, err
-		}
-
-		err = binary.Read(r, binary.BigEndian, &length)
-		if err != nil {
-			return nil, err
-		}
-
-		var rec Record
-
-		switch format {
-		case TypeRawPacketFlowRecord:
-			rec, err = decodeRawPacketFlow(r)
-			if err != nil {
-				return nil, err
-			}
-		case TypeExtendedSwitchFlowRecord:
-			rec, err = decodedExtendedSwitchFlow(r)
-			if err != nil {
-				return nil, err
-			}
-
-		default:
-			_, err := r.Seek(int64(length), 1)
-			if err != nil {
-				return nil, err
-			}
-
-			continue
-		}
-
-		s.Records = append(s.Records, rec)
+	output, err := s.Output.compact()
+	if err != nil {
+		return err
 	}
 
-	return s, nil
-}
-
-func (s *FlowSample) encode(w io.Writer) error {
-	var err error
-
 	// We first need to encode the records.
 	buf := &bytes.Buffer{}
 
 	for _, rec := range s.Records {
-		err = rec.encode(buf)
+		err = flowRecordFormats.encode(buf, rec)
 		if err != nil {
 			return ErrEncodingRecord
 		}
 	}
 
 	// Fields
-	encodedSampleSize := uint32(4 + 1 + 3 + 4 + 4 + 4 + 4 + 4 + 4)
+	encodedSampleSize := uint32(4 + 4 + 4 + 4 + 4 + 4 + 4 + 4)
 
 	// Encoded records
 	encodedSampleSize += uint32(buf.Len())
@@ -188,8 +159,7 @@ func (s *FlowSample) encode(w io.Writer) error {
 	if err != nil {
 		return err
 	}
-	err = binary.Write(w, binary.BigEndian,
-		uint32(s.SourceIdType)|s.SourceIdIndexVal<<24)
+	err = binary.Write(w, binary.BigEndian, source)
 	if err != nil {
 		return err
 	}
@@ -205,11 +175,11 @@ func (s *FlowSample) encode(w io.Writer) error {
 	if err != nil {
 		return err
 	}
-	err = binary.Write(w, binary.BigEndian, s.Input)
+	err = binary.Write(w, binary.BigEndian, input)
 	if err != nil {
 		return err
 	}
-	err = binary.Write(w, binary.BigEndian, s.Output)
+	err = binary.Write(w, binary.BigEndian, output)
 	if err != nil {
 		return err
 	}
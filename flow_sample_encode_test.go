@@ -3,6 +3,7 @@ package sflow
 import (
 	"bytes"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -47,8 +48,7 @@ func TestDecodeEncodeAndDecodeFlowSample(t *testing.T) {
 	var skip [8]byte
 	buf.Read(skip[:])
 
-	// bytes.Buffer is not an io.ReadSeeker. bytes.Reader is.
-	decodedSample, err := decodeFlowSample(bytes.NewReader(buf.Bytes()))
+	decodedSample, err := decodeSample(TypeFlowSample, buf.Bytes(), nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -83,3 +83,41 @@ func TestDecodeEncodeAndDecodeFlowSample(t *testing.T) {
 		t.Errorf("expected FrameLength to be 128, got %d", rec.HeaderSize)
 	}
 }
+
+func TestEncodeDecodeExpandedFlowSample(t *testing.T) {
+	sample := &ExpandedFlowSample{
+		SequenceNum:  12,
+		Source:       DataSource{Type: DataSourceIfIndex, Index: 1 << 28},
+		SamplingRate: 1000,
+		SamplePool:   123000,
+		Drops:        1,
+		Input:        NewInterface(1 << 31),
+		Output:       NewMultipleInterfaces(3),
+		Records: []Record{
+			ExtendedSwitchFlow{SourceVlan: 10, DestinationVlan: 20},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// We need to skip the first 8 bytes. That's the header.
+	var skip [8]byte
+	buf.Read(skip[:])
+
+	decodedSample, err := decodeSample(TypeExpandedFlowSample, buf.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// numRecords is only set by the decoder.
+	sample.numRecords = uint32(len(sample.Records))
+
+	if !reflect.DeepEqual(decodedSample, sample) {
+		t.Errorf("expected\n%v, got\n%v", sample, decodedSample)
+	}
+}
@@ -0,0 +1,150 @@
+package sflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// InterfaceFormat is the format of a flow sample interface,
+// which determines the meaning of its value.
+type InterfaceFormat uint32
+
+const (
+	InterfaceSingle    InterfaceFormat = 0 // ifIndex of a single interface
+	InterfaceDiscarded InterfaceFormat = 1 // packet discarded, with a reason code
+	InterfaceMultiple  InterfaceFormat = 2 // multiple interfaces, with a count
+)
+
+// InterfaceUnknown is the index of an unknown interface.
+const InterfaceUnknown = 0x3fffffff
+
+var ErrInterfaceOverflow = errors.New("sflow: interface too large for compact encoding")
+
+// Interface is the input or output interface of a flow sample.
+type Interface struct {
+	format InterfaceFormat
+	value  uint32
+}
+
+// NewInterface returns the interface with the given ifIndex.
+func NewInterface(index uint32) Interface {
+	return Interface{format: InterfaceSingle, value: index}
+}
+
+// NewDiscardedInterface returns the output interface of a
+// packet that was discarded for the given reason.
+func NewDiscardedInterface(reason uint32) Interface {
+	return Interface{format: InterfaceDiscarded, value: reason}
+}
+
+// NewMultipleInterfaces returns the output interface of a packet
+// that was sent to count interfaces. A count of 0 means unknown.
+func NewMultipleInterfaces(count uint32) Interface {
+	return Interface{format: InterfaceMultiple, value: count}
+}
+
+// Format returns the format of i.
+func (i Interface) Format() InterfaceFormat {
+	return i.format
+}
+
+// Index returns the ifIndex of i, or 0 if i isn't a single interface.
+func (i Interface) Index() uint32 {
+	if i.format != InterfaceSingle {
+		return 0
+	}
+
+	return i.value
+}
+
+// DiscardReason returns the reason the packet was discarded,
+// or 0 if i isn't a discarded interface.
+func (i Interface) DiscardReason() uint32 {
+	if i.format != InterfaceDiscarded {
+		return 0
+	}
+
+	return i.value
+}
+
+// Count returns the number of interfaces,
+// or 0 if i isn't a multiple interfaces value.
+func (i Interface) Count() uint32 {
+	if i.format != InterfaceMultiple {
+		return 0
+	}
+
+	return i.value
+}
+
+func (i Interface) String() string {
+	switch i.format {
+	case InterfaceSingle:
+		return fmt.Sprint(i.value)
+	case InterfaceDiscarded:
+		return fmt.Sprintf("discarded(%d)", i.value)
+	case InterfaceMultiple:
+		return fmt.Sprintf("multiple(%d)", i.value)
+	default:
+		return fmt.Sprintf("format%d(%d)", i.format, i.value)
+	}
+}
+
+// interfaceJSON is the JSON representation of interfaces
+// other than single ones.
+type interfaceJSON struct {
+	Format InterfaceFormat `json:"format"`
+	Value  uint32          `json:"value"`
+}
+
+// MarshalJSON encodes a single interface as its ifIndex, and
+// others as an object with their format and value.
+func (i Interface) MarshalJSON() ([]byte, error) {
+	if i.format == InterfaceSingle {
+		return json.Marshal(i.value)
+	}
+
+	return json.Marshal(interfaceJSON{Format: i.format, Value: i.value})
+}
+
+// UnmarshalJSON decodes an interface encoded by MarshalJSON.
+func (i *Interface) UnmarshalJSON(b []byte) error {
+	var index uint32
+
+	err := json.Unmarshal(b, &index)
+	if err == nil {
+		*i = NewInterface(index)
+		return nil
+	}
+
+	var v interfaceJSON
+
+	err = json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+
+	*i = Interface{format: v.Format, value: v.Value}
+
+	return nil
+}
+
+// decodeInterface decodes an interface from its compact
+// representation, which has the format in the upper 2 bits
+// and the value in the lower 30 bits.
+func decodeInterface(v uint32) Interface {
+	return Interface{
+		format: InterfaceFormat(v >> 30),
+		value:  v & (1<<30 - 1),
+	}
+}
+
+// compact returns the compact representation of i.
+func (i Interface) compact() (uint32, error) {
+	if i.format >= 1<<2 || i.value >= 1<<30 {
+		return 0, ErrInterfaceOverflow
+	}
+
+	return uint32(i.format)<<30 | i.value, nil
+}
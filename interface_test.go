@@ -0,0 +1,104 @@
+package sflow
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestInterface(t *testing.T) {
+	cases := []struct {
+		compact       uint32
+		iface         Interface
+		index         uint32
+		discardReason uint32
+		count         uint32
+		str           string
+	}{
+		{0x00000004, NewInterface(4), 4, 0, 0, "4"},
+		{0x3fffffff, NewInterface(InterfaceUnknown), InterfaceUnknown, 0, 0, "1073741823"},
+		{0x40000103, NewDiscardedInterface(259), 0, 259, 0, "discarded(259)"},
+		{0x80000007, NewMultipleInterfaces(7), 0, 0, 7, "multiple(7)"},
+	}
+
+	for _, c := range cases {
+		iface := decodeInterface(c.compact)
+		if iface != c.iface {
+			t.Errorf("%#x: expected %v, got %v", c.compact, c.iface, iface)
+		}
+
+		if iface.Index() != c.index {
+			t.Errorf("%v: expected index %d, got %d", iface, c.index, iface.Index())
+		}
+
+		if iface.DiscardReason() != c.discardReason {
+			t.Errorf("%v: expected discard reason %d, got %d", iface, c.discardReason, iface.DiscardReason())
+		}
+
+		if iface.Count() != c.count {
+			t.Errorf("%v: expected count %d, got %d", iface, c.count, iface.Count())
+		}
+
+		if iface.String() != c.str {
+			t.Errorf("expected %q, got %q", c.str, iface.String())
+		}
+
+		v, err := iface.compact()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v != c.compact {
+			t.Errorf("%v: expected compact interface %#x, got %#x", iface, c.compact, v)
+		}
+	}
+
+	_, err := NewInterface(1 << 30).compact()
+	if err != ErrInterfaceOverflow {
+		t.Errorf("expected %v, got %v", ErrInterfaceOverflow, err)
+	}
+}
+
+func TestInterfaceJSON(t *testing.T) {
+	cases := []struct {
+		iface Interface
+		json  string
+	}{
+		{NewInterface(4), `4`},
+		{NewDiscardedInterface(259), `{"format":1,"value":259}`},
+		{NewMultipleInterfaces(7), `{"format":2,"value":7}`},
+	}
+
+	for _, c := range cases {
+		b, err := json.Marshal(c.iface)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != c.json {
+			t.Errorf("%v: expected %s, got %s", c.iface, c.json, b)
+		}
+
+		var iface Interface
+
+		err = json.Unmarshal(b, &iface)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if iface != c.iface {
+			t.Errorf("expected %v, got %v", c.iface, iface)
+		}
+	}
+
+	// Flow samples encode their interfaces like the ifIndex
+	// fields they replaced.
+	b, err := json.Marshal(&FlowSample{Input: NewInterface(4), Output: NewDiscardedInterface(259)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"Input":4,"Output":{"format":1,"value":259}`) {
+		t.Errorf("expected the interfaces to be encoded, got %s", b)
+	}
+}
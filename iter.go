@@ -0,0 +1,84 @@
+package sflow
+
+import "reflect"
+
+// samplesOf returns an iterator over the samples of d of type T.
+func samplesOf[T Sample](d *Datagram) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for _, sample := range d.Samples {
+			s, ok := sample.(T)
+			if !ok {
+				continue
+			}
+
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// recordsOf returns an iterator over the records of s of type T.
+// RawRecord values kept by a lazy decoder are decoded, unless their
+// data format isn't that of T, and skipped if that fails. Records
+// held as pointers to T, as in datagrams decoded into, are yielded
+// as values.
+func recordsOf[T Record](s Sample) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		dataFormat, ok := recordTypeOf[T]()
+
+		for i, rec := range s.GetRecords() {
+			switch raw := rec.(type) {
+			case RawRecord:
+				if ok && raw.DataFormat != dataFormat {
+					continue
+				}
+			case *RawRecord:
+				if ok && raw.DataFormat != dataFormat {
+					continue
+				}
+			}
+
+			rec, err := s.Record(i)
+			if err != nil {
+				continue
+			}
+
+			var r T
+
+			switch rec := any(rec).(type) {
+			case T:
+				r = rec
+			case *T:
+				r = *rec
+			default:
+				continue
+			}
+
+			if !yield(r) {
+				return
+			}
+		}
+	}
+}
+
+// recordTypeOf returns the data format of records of type T,
+// if it's the same for every record of that type.
+func recordTypeOf[T Record]() (DataFormat, bool) {
+	var zero T
+
+	switch reflect.TypeOf(&zero).Elem().Kind() {
+	case reflect.Interface, reflect.Pointer:
+		// The zero value of T is nil, and RecordType
+		// may not be called on it.
+		return 0, false
+	}
+
+	switch any(zero).(type) {
+	case UnknownRecord, RawRecord:
+		// Records of T have different data formats.
+		return 0, false
+	}
+
+	return zero.RecordType(), true
+}
@@ -0,0 +1,26 @@
+//go:build !go1.23
+
+package sflow
+
+// SamplesOf returns an iterator over the samples of d of type T,
+// such as *FlowSample.
+func SamplesOf[T Sample](d *Datagram) func(yield func(T) bool) {
+	return samplesOf[T](d)
+}
+
+// FlowSamples returns an iterator over the flow samples of d.
+func (d *Datagram) FlowSamples() func(yield func(*FlowSample) bool) {
+	return samplesOf[*FlowSample](d)
+}
+
+// CounterSamples returns an iterator over the counter samples of d.
+func (d *Datagram) CounterSamples() func(yield func(*CounterSample) bool) {
+	return samplesOf[*CounterSample](d)
+}
+
+// RecordsOf returns an iterator over the records of s of type T,
+// such as RawPacketFlow. Records kept by a lazy decoder are decoded
+// as needed, and skipped if they fail to decode.
+func RecordsOf[T Record](s Sample) func(yield func(T) bool) {
+	return recordsOf[T](s)
+}
@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package sflow
+
+import "iter"
+
+// SamplesOf returns an iterator over the samples of d of type T,
+// such as *FlowSample.
+func SamplesOf[T Sample](d *Datagram) iter.Seq[T] {
+	return samplesOf[T](d)
+}
+
+// FlowSamples returns an iterator over the flow samples of d.
+func (d *Datagram) FlowSamples() iter.Seq[*FlowSample] {
+	return samplesOf[*FlowSample](d)
+}
+
+// CounterSamples returns an iterator over the counter samples of d.
+func (d *Datagram) CounterSamples() iter.Seq[*CounterSample] {
+	return samplesOf[*CounterSample](d)
+}
+
+// RecordsOf returns an iterator over the records of s of type T,
+// such as RawPacketFlow. Records kept by a lazy decoder are decoded
+// as needed, and skipped if they fail to decode.
+func RecordsOf[T Record](s Sample) iter.Seq[T] {
+	return recordsOf[T](s)
+}
@@ -0,0 +1,134 @@
+//go:build go1.23
+
+package sflow
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestIterSamples(t *testing.T) {
+	dgram, err := DecodeBytes(mixedDatagram(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var flows []*FlowSample
+	for s := range dgram.FlowSamples() {
+		flows = append(flows, s)
+	}
+
+	if len(flows) != 1 || flows[0] != dgram.Samples[0] {
+		t.Errorf("expected the flow sample, got %v", flows)
+	}
+
+	var counters []*CounterSample
+	for s := range dgram.CounterSamples() {
+		counters = append(counters, s)
+	}
+
+	if len(counters) != 1 || counters[0] != dgram.Samples[1] {
+		t.Errorf("expected the counter sample, got %v", counters)
+	}
+
+	n := 0
+	for range SamplesOf[Sample](dgram) {
+		n++
+		break
+	}
+
+	if n != 1 {
+		t.Errorf("expected to stop after 1 sample, got %d", n)
+	}
+}
+
+func TestIterRecords(t *testing.T) {
+	b := mixedDatagram(t)
+
+	expected, err := DecodeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(bytes.NewReader(b))
+	d.SetOptions(DecoderOptions{Lazy: true})
+
+	dgram, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var flows []RawPacketFlow
+	for s := range dgram.FlowSamples() {
+		for rec := range RecordsOf[RawPacketFlow](s) {
+			flows = append(flows, rec)
+		}
+	}
+
+	if len(flows) != 1 || !reflect.DeepEqual(flows[0], expected.Samples[0].GetRecords()[0]) {
+		t.Errorf("expected\n%v, got\n%v", expected.Samples[0].GetRecords()[0], flows)
+	}
+
+	// Only the records of the type iterated over are decoded.
+	records := dgram.Samples[0].GetRecords()
+	if _, ok := records[0].(RawPacketFlow); !ok {
+		t.Errorf("expected a decoded RawPacketFlow, got %T", records[0])
+	}
+
+	if _, ok := records[1].(RawRecord); !ok {
+		t.Errorf("expected a RawRecord, got %T", records[1])
+	}
+
+	// Records decoded into a datagram are held as pointers,
+	// and yielded as values.
+	reused := &Datagram{}
+
+	err = DecodeBytesInto(b, reused)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flows = flows[:0]
+	for rec := range RecordsOf[RawPacketFlow](reused.Samples[0]) {
+		flows = append(flows, rec)
+	}
+
+	if len(flows) != 1 || !reflect.DeepEqual(flows[0], expected.Samples[0].GetRecords()[0]) {
+		t.Errorf("expected\n%v, got\n%v", expected.Samples[0].GetRecords()[0], flows)
+	}
+
+	var counters []Record
+	for rec := range RecordsOf[Record](dgram.Samples[1]) {
+		counters = append(counters, rec)
+	}
+
+	if !reflect.DeepEqual(counters, expected.Samples[1].GetRecords()) {
+		t.Errorf("expected\n%v, got\n%v", expected.Samples[1].GetRecords(), counters)
+	}
+}
+
+func TestIterRecordsPointer(t *testing.T) {
+	b := mixedDatagram(t)
+
+	for _, lazy := range []bool{false, true} {
+		d := NewDecoder(bytes.NewReader(b))
+		d.SetOptions(DecoderOptions{Lazy: lazy})
+
+		dgram, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Records are held as values, so there are none
+		// of a pointer type, but iterating mustn't panic.
+		n := 0
+		for range RecordsOf[*HostDiskCounters](dgram.Samples[1]) {
+			n++
+		}
+
+		if n != 0 {
+			t.Errorf("lazy %v: expected no records, got %d", lazy, n)
+		}
+	}
+}
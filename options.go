@@ -0,0 +1,149 @@
+package sflow
+
+import "slices"
+
+// DecoderOptions configures how a Decoder decodes datagrams.
+// The zero value is the default configuration.
+type DecoderOptions struct {
+	// Lenient makes the decoder skip samples and records that fail
+	// to decode, rather than fail the whole datagram, and add their
+	// errors to Datagram.Errors. Samples and records that only have
+	// trailing bytes are kept. Decoding stops at the first sample
+	// that overruns the datagram, as the samples following it can't
+	// be found.
+	Lenient bool
+
+	// Lazy makes the decoder keep records as RawRecord values, which
+	// are decoded when accessed through Sample.Record. Errors in the
+	// data of records are then returned by Sample.Record rather than
+	// when decoding the datagram. RawRecord values decode with the
+	// options they were kept with, so those shouldn't be modified
+	// while the datagram is in use.
+	Lazy bool
+
+	// SampleTypes, if not nil, are the formats of the samples
+	// to decode. Other samples are skipped by their length and
+	// left out of Datagram.Samples.
+	SampleTypes []DataFormat
+
+	// FlowRecordTypes and CounterRecordTypes, if not nil, are the
+	// data formats of the flow and counter records to decode.
+	// Other records are skipped by their length and left out of
+	// the records of their sample.
+	FlowRecordTypes    []DataFormat
+	CounterRecordTypes []DataFormat
+
+	// MaximumRecordLength is the maximum length of the samples and
+	// records to decode. If zero, the package MaximumRecordLength
+	// is used.
+	MaximumRecordLength uint32
+
+	// MaximumHeaderLength is the maximum length of the packet headers
+	// of RawPacketFlow records. If zero, the package
+	// MaximumHeaderLength is used.
+	MaximumHeaderLength uint32
+}
+
+var defaultDecoderOptions = DecoderOptions{}
+
+// SetOptions sets the options of the decoder.
+func (d *Decoder) SetOptions(options DecoderOptions) {
+	d.options = options
+}
+
+// DecodeBytes decodes the datagram in b with the options o,
+// like the package DecodeBytes.
+func (o *DecoderOptions) DecodeBytes(b []byte) (*Datagram, error) {
+	return o.DecodeBytesNoCopy(append([]byte(nil), b...))
+}
+
+// DecodeBytesNoCopy decodes the datagram in b with the options o,
+// like the package DecodeBytesNoCopy.
+func (o *DecoderOptions) DecodeBytesNoCopy(b []byte) (*Datagram, error) {
+	dgram := &Datagram{}
+
+	err := decodeInto(b, dgram, o, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return dgram, nil
+}
+
+// DecodeBytesInto decodes the datagram in b into dst with the
+// options o, like the package DecodeBytesInto.
+func (o *DecoderOptions) DecodeBytesInto(b []byte, dst *Datagram) error {
+	return decodeInto(b, dst, o, true)
+}
+
+func (o *DecoderOptions) maximumRecordLength() uint32 {
+	if o.MaximumRecordLength == 0 {
+		return MaximumRecordLength
+	}
+
+	return o.MaximumRecordLength
+}
+
+func (o *DecoderOptions) maximumHeaderLength() uint32 {
+	if o.MaximumHeaderLength == 0 {
+		return MaximumHeaderLength
+	}
+
+	return o.MaximumHeaderLength
+}
+
+// decodeState is what decoding a datagram depends on besides its
+// bytes, and is passed down to its samples and records.
+type decodeState struct {
+	options *DecoderOptions
+
+	// errors are the errors of the samples and records
+	// skipped in lenient mode.
+	errors []error
+	// records, if not nil, is the store of the datagram decoded
+	// into, which records are decoded into.
+	records *recordStore
+}
+
+func (st *decodeState) lenient() bool {
+	return st.opts().Lenient
+}
+
+// opts returns the options of the decoding, which are the default
+// options if there's no state or options, e.g. for a RawRecord that
+// wasn't kept by a decoder.
+func (st *decodeState) opts() *DecoderOptions {
+	if st == nil || st.options == nil {
+		return &defaultDecoderOptions
+	}
+
+	return st.options
+}
+
+// store returns the store records are decoded into,
+// which is nil unless decoding into a datagram.
+func (st *decodeState) store() *recordStore {
+	if st == nil {
+		return nil
+	}
+
+	return st.records
+}
+
+// decodesSample reports whether samples with the given format
+// are decoded rather than skipped.
+func (st *decodeState) decodesSample(format DataFormat) bool {
+	types := st.opts().SampleTypes
+	return types == nil || slices.Contains(types, format)
+}
+
+// decodesRecord reports whether records with the given data format
+// are decoded rather than skipped.
+func (st *decodeState) decodesRecord(f *recordFormats, dataFormat DataFormat) bool {
+	types := st.opts().CounterRecordTypes
+	if f == flowRecordFormats {
+		types = st.opts().FlowRecordTypes
+	}
+
+	return types == nil || slices.Contains(types, dataFormat)
+}
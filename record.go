@@ -1,8 +1,12 @@
 package sflow
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"sync"
 )
 
 var (
@@ -10,7 +14,466 @@ var (
 	ErrDecodingRecord = errors.New("sflow: failed to decode record")
 )
 
+// Record is a flow or counter record. RecordType returns
+// the data format of the record.
 type Record interface {
-	RecordType() int
-	encode(w io.Writer) error
+	RecordType() DataFormat
+}
+
+// UnknownRecord is a record whose data format isn't registered.
+// Its data is kept as is, so that it's encoded byte for byte.
+type UnknownRecord struct {
+	DataFormat DataFormat
+	Data       []byte
+}
+
+func (r UnknownRecord) String() string {
+	type X UnknownRecord
+	x := X(r)
+	return fmt.Sprintf("UnknownRecord: %+v", x)
+}
+
+// RecordType returns the data format of the record.
+func (r UnknownRecord) RecordType() DataFormat {
+	return r.DataFormat
+}
+
+// RawRecord is a record kept undecoded by a lazy decoder, until
+// it's accessed through Sample.Record. Its data is encoded as is.
+type RawRecord struct {
+	DataFormat DataFormat
+	Data       []byte
+
+	// options are the options of the decoder, which the record
+	// is decoded with.
+	options *DecoderOptions
+
+	// store, if not nil, is the record store of the datagram the
+	// record was kept in, which it's decoded into as well.
+	store *recordStore
+}
+
+func (r RawRecord) String() string {
+	return fmt.Sprintf("RawRecord: {DataFormat:%v Data:%v}", r.DataFormat, r.Data)
+}
+
+// RecordType returns the data format of the record.
+func (r RawRecord) RecordType() DataFormat {
+	return r.DataFormat
+}
+
+// RecordDecoder decodes the data of a record, which excludes the
+// data format and length preceding it in the datagram.
+type RecordDecoder func(b []byte) (Record, error)
+
+// RecordEncoder writes the data of rec to w. The data format and
+// length preceding the data are written by the caller.
+type RecordEncoder func(w io.Writer, rec Record) error
+
+// RegisterFlowRecord registers the decoder and encoder for the flow
+// record with the given enterprise and format numbers. Flow records
+// are used by flow samples and discarded packet events.
+// Registering a data format again replaces the previous registration,
+// including that of a built-in record.
+func RegisterFlowRecord(enterprise, format uint32, decode RecordDecoder, encode RecordEncoder) {
+	flowRecordFormats.register(NewDataFormat(enterprise, format), decode, encode)
+}
+
+// RegisterCounterRecord registers the decoder and encoder for the
+// counter record with the given enterprise and format numbers.
+// Registering a data format again replaces the previous registration,
+// including that of a built-in record.
+func RegisterCounterRecord(enterprise, format uint32, decode RecordDecoder, encode RecordEncoder) {
+	counterRecordFormats.register(NewDataFormat(enterprise, format), decode, encode)
+}
+
+type recordFormat struct {
+	decode RecordDecoder
+	encode RecordEncoder
+
+	// decodeBuiltIn, if set, decodes the data of a built-in record,
+	// and returns the number of bytes decoded. If store isn't nil,
+	// the record is decoded into it, and returned as a pointer.
+	decodeBuiltIn func(b []byte, store *recordStore) (Record, int, error)
+}
+
+// recordFormats maps data formats to the functions that decode
+// and encode records of that format.
+type recordFormats struct {
+	lock    sync.RWMutex
+	formats map[DataFormat]recordFormat
+}
+
+var (
+	flowRecordFormats    = &recordFormats{formats: map[DataFormat]recordFormat{}}
+	counterRecordFormats = &recordFormats{formats: map[DataFormat]recordFormat{}}
+)
+
+func (f *recordFormats) register(dataFormat DataFormat, decode RecordDecoder, encode RecordEncoder) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.formats[dataFormat] = recordFormat{
+		decode: decode,
+		encode: encode,
+	}
+}
+
+func (f *recordFormats) lookup(dataFormat DataFormat) (recordFormat, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	rf, ok := f.formats[dataFormat]
+	return rf, ok
+}
+
+// registerRecord registers a built-in record of type T, whose data
+// decode decodes into a record, returning the number of bytes decoded.
+// Unlike records registered with a RecordDecoder, their length is
+// checked. P is the pointer type of T, as records decoded into a
+// datagram are.
+func registerRecord[T Record, P interface {
+	*T
+	Record
+}](f *recordFormats, dataFormat DataFormat, decode func(b []byte, r *T) (int, error), encode RecordEncoder) {
+	decodeBuiltIn := func(b []byte, store *recordStore) (Record, int, error) {
+		if store != nil {
+			r := storeRecord[T](store, f, dataFormat)
+
+			n, err := decode(b, r)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			return P(r), n, nil
+		}
+
+		var r T
+
+		n, err := decode(b, &r)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return r, n, nil
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.formats[dataFormat] = recordFormat{
+		decode: func(b []byte) (Record, error) {
+			rec, _, err := decodeBuiltIn(b, nil)
+			return rec, err
+		},
+		encode: func(w io.Writer, rec Record) error {
+			// Records decoded into a datagram are pointers.
+			if r, ok := rec.(P); ok {
+				rec = *r
+			}
+
+			return encode(w, rec)
+		},
+		decodeBuiltIn: decodeBuiltIn,
+	}
+}
+
+// decode decodes the data of a record with the given data format.
+// Records with unregistered formats are returned as UnknownRecord
+// values. The record may reference b. The number of bytes decoded
+// is returned as well, which is len(b) unless the record is built in.
+func (f *recordFormats) decode(dataFormat DataFormat, b []byte) (Record, int, error) {
+	return f.decodeStored(dataFormat, b, nil)
+}
+
+// decodeStored decodes a record like decode. If store isn't nil,
+// built-in and unknown records are decoded into it, and returned
+// as pointers.
+func (f *recordFormats) decodeStored(dataFormat DataFormat, b []byte, store *recordStore) (Record, int, error) {
+	rf, ok := f.lookup(dataFormat)
+	if !ok {
+		if store != nil {
+			r := storeRecord[UnknownRecord](store, f, dataFormat)
+			*r = UnknownRecord{DataFormat: dataFormat, Data: b}
+			return r, len(b), nil
+		}
+
+		return UnknownRecord{DataFormat: dataFormat, Data: b}, len(b), nil
+	}
+
+	if rf.decodeBuiltIn == nil {
+		rec, err := rf.decode(b)
+		return rec, len(b), err
+	}
+
+	return rf.decodeBuiltIn(b, store)
+}
+
+// decodeRecords decodes n records from b[offset:], each preceded by
+// its data format and length, and appends them to records[:0].
+// Records whose data format isn't decoded are skipped.
+// It returns ErrLengthOverrun if b is too short for the records, and
+// a *DecodeError with the offset of the record in b if a record fails
+// to decode, unless decoding is lenient, in which case the record is
+// skipped. The offset following the last record is returned as well.
+func (f *recordFormats) decodeRecords(b []byte, offset int, n uint32, records []Record, st *decodeState) ([]Record, int, error) {
+	if records == nil {
+		// Each record takes at least 8 bytes, which bounds
+		// the allocation if n is bogus.
+		records = make([]Record, 0, min(int(n), (len(b)-offset)/(2*4)))
+	}
+
+	records = records[:0]
+
+	for i := uint32(0); i < n; i++ {
+		if len(b)-offset < 2*4 {
+			return nil, 0, ErrLengthOverrun
+		}
+
+		dataFormat := DataFormat(binary.BigEndian.Uint32(b[offset:]))
+		length := binary.BigEndian.Uint32(b[offset+4:])
+		start := offset
+		offset += 2 * 4
+
+		if int(length) > len(b)-offset {
+			// The sample is too short for the record.
+			return nil, 0, ErrLengthOverrun
+		}
+
+		if !st.decodesRecord(f, dataFormat) {
+			offset += int(length)
+			continue
+		}
+
+		var rec Record
+		var err error
+
+		end := offset + int(length)
+
+		if maximum := st.opts().maximumRecordLength(); length > maximum {
+			err = fmt.Errorf("sflow: record length more than %d: %d",
+				maximum, length)
+		} else if st.opts().Lazy {
+			// The record is decoded when it's accessed.
+			raw := RawRecord{DataFormat: dataFormat, Data: b[offset:end:end], options: st.opts()}
+
+			if store := st.store(); store != nil {
+				r := storeRecord[RawRecord](store, f, dataFormat)
+				raw.store = store
+				*r = raw
+				rec = r
+			} else {
+				rec = raw
+			}
+		} else {
+			rec, err = f.decodeRecord(dataFormat, b[offset:end:end], st)
+		}
+
+		if err != nil {
+			err = &DecodeError{
+				Offset:     start,
+				Sample:     -1,
+				Record:     int(i),
+				DataFormat: dataFormat,
+				Err:        err,
+			}
+
+			if !st.lenient() {
+				return nil, 0, err
+			}
+
+			st.errors = append(st.errors, err)
+		}
+
+		if rec != nil {
+			records = append(records, rec)
+		}
+
+		offset += int(length)
+	}
+
+	return records, offset, nil
+}
+
+// decodeRecord decodes the data of a record with the given data
+// format from b. It returns a *LengthError if the data doesn't match len(b),
+// along with the record if it only has trailing bytes.
+func (f *recordFormats) decodeRecord(dataFormat DataFormat, b []byte, st *decodeState) (Record, error) {
+	rec, decoded, err := f.decodeStored(dataFormat, b, st.store())
+	if err == ErrInvalidSliceLength || err == ErrDecodingRecord {
+		// The record decoder ran out of data.
+		err = &LengthError{
+			Err:        ErrLengthOverrun,
+			DataFormat: dataFormat,
+			Length:     len(b),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The header length is checked here rather than by the record
+	// decoder, as its maximum depends on the options.
+	var headerSize uint32
+
+	switch f := rec.(type) {
+	case RawPacketFlow:
+		headerSize = f.HeaderSize
+	case *RawPacketFlow:
+		headerSize = f.HeaderSize
+	}
+
+	if maximum := st.opts().maximumHeaderLength(); headerSize > maximum {
+		return nil, fmt.Errorf("sflow: header length more than %d: %d",
+			maximum, headerSize)
+	}
+
+	if decoded < len(b) {
+		return rec, &LengthError{
+			Err:        ErrLengthUnderrun,
+			DataFormat: dataFormat,
+			Length:     len(b),
+			Trailing:   len(b) - decoded,
+		}
+	}
+
+	return rec, nil
+}
+
+// record returns records[i], decoding it first if it's a RawRecord,
+// in which case it's replaced by the decoded record.
+func (f *recordFormats) record(records []Record, i int) (Record, error) {
+	var raw RawRecord
+
+	switch r := records[i].(type) {
+	case RawRecord:
+		raw = r
+	case *RawRecord:
+		raw = *r
+	default:
+		return records[i], nil
+	}
+
+	rec, err := f.decodeRecord(raw.DataFormat, raw.Data, &decodeState{options: raw.options, records: raw.store})
+	if err != nil {
+		return nil, err
+	}
+
+	records[i] = rec
+
+	return rec, nil
+}
+
+// encode writes rec to w, preceded by its data format and length.
+func (f *recordFormats) encode(w io.Writer, rec Record) error {
+	var err error
+
+	// The length precedes the data, so we need to encode it first.
+	buf := &bytes.Buffer{}
+
+	switch r := rec.(type) {
+	case UnknownRecord:
+		buf.Write(r.Data)
+	case *UnknownRecord:
+		buf.Write(r.Data)
+	case RawRecord:
+		buf.Write(r.Data)
+	case *RawRecord:
+		buf.Write(r.Data)
+	default:
+		rf, ok := f.lookup(rec.RecordType())
+		if !ok {
+			return ErrEncodingRecord
+		}
+
+		err = rf.encode(buf, rec)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint32(rec.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint32(buf.Len()))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, buf)
+	return err
+}
+
+// encodeFixedRecord is the RecordEncoder for records made of
+// fixed-size fields only.
+func encodeFixedRecord(w io.Writer, rec Record) error {
+	return binary.Write(w, binary.BigEndian, rec)
+}
+
+// recordStore holds the records decoded into a datagram by
+// Decoder.DecodeInto and DecodeBytesInto, which are decoded into
+// again by the next call rather than allocated. Boxing a record value
+// in a Record allocates, so records are held and returned as pointers.
+type recordStore struct {
+	slabs map[recordKey]recordSlab
+}
+
+// recordKey identifies the records of a data format, which may differ
+// between flow and counter records.
+type recordKey struct {
+	formats    *recordFormats
+	dataFormat DataFormat
+}
+
+type recordSlab interface {
+	reset()
+}
+
+// slab holds the records of a type. The first n are in use.
+type slab[T any] struct {
+	records []*T
+	n       int
+}
+
+func (s *slab[T]) reset() {
+	s.n = 0
+}
+
+// reset makes the records of the store available to decode into.
+// Records previously returned must no longer be used.
+func (s *recordStore) reset() {
+	for _, slab := range s.slabs {
+		slab.reset()
+	}
+}
+
+// storeRecord returns a zeroed record of type T with the given
+// data format from the store, which is allocated only if the store
+// has no record available.
+func storeRecord[T any](s *recordStore, f *recordFormats, dataFormat DataFormat) *T {
+	key := recordKey{formats: f, dataFormat: dataFormat}
+
+	sl, ok := s.slabs[key].(*slab[T])
+	if !ok {
+		if s.slabs == nil {
+			s.slabs = map[recordKey]recordSlab{}
+		}
+
+		sl = &slab[T]{}
+		s.slabs[key] = sl
+	}
+
+	if sl.n == len(sl.records) {
+		sl.records = append(sl.records, new(T))
+	}
+
+	r := sl.records[sl.n]
+	sl.n++
+
+	var zero T
+	*r = zero
+
+	return r
 }
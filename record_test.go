@@ -0,0 +1,133 @@
+package sflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+type testEnterpriseCounters struct {
+	Value uint32
+}
+
+func (c testEnterpriseCounters) RecordType() DataFormat {
+	return NewDataFormat(99999, 7)
+}
+
+func TestRegisterCounterRecord(t *testing.T) {
+	RegisterCounterRecord(99999, 7,
+		func(b []byte) (Record, error) {
+			if len(b) != 4 {
+				return nil, ErrDecodingRecord
+			}
+
+			return testEnterpriseCounters{Value: binary.BigEndian.Uint32(b)}, nil
+		},
+		func(w io.Writer, rec Record) error {
+			return binary.Write(w, binary.BigEndian, rec.(testEnterpriseCounters).Value)
+		},
+	)
+
+	rec := testEnterpriseCounters{Value: 42}
+
+	sample := &CounterSample{
+		SequenceNum: 1,
+		Records:     []Record{rec, HumidityCounters{Relative: 40}},
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// We need to skip the first 8 bytes. That's the header.
+	var skip [8]byte
+	buf.Read(skip[:])
+
+	decodedSample, err := decodeSample(TypeCounterSample, buf.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := decodedSample.GetRecords()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	if records[0] != rec {
+		t.Errorf("expected\n%#v, got\n%#v", rec, records[0])
+	}
+
+	if records[1] != (HumidityCounters{Relative: 40}) {
+		t.Errorf("expected HumidityCounters, got\n%#v", records[1])
+	}
+}
+
+func TestEncodeUnregisteredRecord(t *testing.T) {
+	sample := &FlowSample{
+		Records: []Record{testEnterpriseCounters{}},
+	}
+
+	err := sample.encode(&bytes.Buffer{})
+	if err != ErrEncodingRecord {
+		t.Errorf("expected %v, got %v", ErrEncodingRecord, err)
+	}
+}
+
+func TestDecodeLengthErrors(t *testing.T) {
+	// counterSample returns the data of a counter sample with
+	// humidity records, whose data is 4 bytes.
+	counterSample := func(numRecords, recordLength uint32, data []byte, trailing int) []byte {
+		buf := &bytes.Buffer{}
+		binary.Write(buf, binary.BigEndian, []uint32{1, 0, numRecords,
+			uint32(TypeHumidityCountersRecord), recordLength})
+		buf.Write(data)
+		buf.Write(make([]byte, trailing))
+		return buf.Bytes()
+	}
+
+	_, err := decodeSample(TypeCounterSample, counterSample(1, 4, make([]byte, 4), 0), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		b          []byte
+		err        error
+		dataFormat DataFormat
+		trailing   int
+	}{
+		{"record overrun", counterSample(1, 2, make([]byte, 2), 0), ErrLengthOverrun, TypeHumidityCountersRecord, 0},
+		{"record underrun", counterSample(1, 8, make([]byte, 8), 0), ErrLengthUnderrun, TypeHumidityCountersRecord, 4},
+		{"sample overrun", counterSample(2, 4, make([]byte, 4), 0), ErrLengthOverrun, TypeCounterSample, 0},
+		{"sample underrun", counterSample(1, 4, make([]byte, 4), 4), ErrLengthUnderrun, TypeCounterSample, 4},
+		{"truncated sample", counterSample(1, 4, nil, 0)[:10], ErrLengthOverrun, TypeCounterSample, 0},
+	}
+
+	for _, c := range cases {
+		_, err := decodeSample(TypeCounterSample, c.b, nil, nil)
+
+		var lengthErr *LengthError
+		if !errors.As(err, &lengthErr) {
+			t.Errorf("%s: expected a *LengthError, got %v", c.name, err)
+			continue
+		}
+
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.err, lengthErr.Err)
+		}
+
+		if lengthErr.DataFormat != c.dataFormat {
+			t.Errorf("%s: expected data format %v, got %v", c.name, c.dataFormat, lengthErr.DataFormat)
+		}
+
+		if lengthErr.Trailing != c.trailing {
+			t.Errorf("%s: expected %d trailing bytes, got %d", c.name, c.trailing, lengthErr.Trailing)
+		}
+	}
+}
@@ -3,56 +3,154 @@ package sflow
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 )
 
 const (
-	TypeFlowSample            = 1
-	TypeCounterSample         = 2
-	TypeExpandedFlowSample    = 3
-	TypeExpandedCounterSample = 4
-	TypeEventDiscardedPacket  = 5
+	TypeFlowSample            DataFormat = 1
+	TypeCounterSample         DataFormat = 2
+	TypeExpandedFlowSample    DataFormat = 3
+	TypeExpandedCounterSample DataFormat = 4
+	TypeEventDiscardedPacket  DataFormat = 5
 )
 
 var (
+	// ErrUnknownSampleType is no longer returned by the decoder,
+	// which decodes unknown samples as UnknownSample values.
 	ErrUnknownSampleType = errors.New("sflow: Unknown sample type")
 )
 
 type Sample interface {
-	SampleType() int
+	SampleType() DataFormat
 	GetRecords() []Record
-	encode(w io.Writer) error
-}
 
-func decodeSample(r io.ReadSeeker) (Sample, error) {
-	format, length, err := uint32(0), uint32(0), error(nil)
+	// Record returns the i-th record of the sample. A RawRecord
+	// kept by a lazy decoder is decoded, and replaced by the
+	// decoded record. Record panics if i is out of range.
+	Record(i int) (Record, error)
 
-	err = binary.Read(r, binary.BigEndian, &format)
-	if err != nil {
-		return nil, err
-	}
+	// Validate checks the sample and its records, and returns a
+	// *ValidationError listing every violation found.
+	Validate() error
 
-	err = binary.Read(r, binary.BigEndian, &length)
-	if err != nil {
-		return nil, err
-	}
+	encode(w io.Writer) error
+}
+
+// decodeSample decodes the data of a sample with the given format.
+// If prev is a sample of the same type, it's decoded into rather than
+// allocating a new sample. A *LengthError is returned if the data
+// doesn't match len(b), along with the sample if it only has trailing
+// bytes.
+func decodeSample(format DataFormat, b []byte, prev Sample, st *decodeState) (Sample, error) {
+	var sample Sample
+	var n int
+	var err error
 
 	switch format {
 	case TypeCounterSample:
-		return decodeCounterSample(r)
+		sample, n, err = decodeCounterSample(b, prev, st)
 
 	case TypeFlowSample:
-		return decodeFlowSample(r)
+		sample, n, err = decodeFlowSample(b, prev, st)
+
+	case TypeExpandedFlowSample:
+		sample, n, err = decodeExpandedFlowSample(b, prev, st)
 
 	case TypeEventDiscardedPacket:
-		return decodEventDiscardedPacket(r)
+		sample, n, err = decodEventDiscardedPacket(b, prev, st)
 
 	default:
-		_, err = r.Seek(int64(length), 1)
-		if err != nil {
-			return nil, err
+		sample, n, err = decodeUnknownSample(format, b, prev)
+	}
+
+	if err != nil {
+		var lengthErr *LengthError
+
+		if errors.Is(err, ErrLengthOverrun) && !errors.As(err, &lengthErr) {
+			// The sample itself, rather than one of its
+			// records, ran out of data.
+			err = &LengthError{
+				Err:        ErrLengthOverrun,
+				DataFormat: format,
+				Length:     len(b),
+			}
 		}
 
-		return nil, ErrUnknownSampleType
+		return nil, err
+	}
+
+	if n < len(b) {
+		return sample, &LengthError{
+			Err:        ErrLengthUnderrun,
+			DataFormat: format,
+			Length:     len(b),
+			Trailing:   len(b) - n,
+		}
 	}
+
+	return sample, nil
+}
+
+// UnknownSample is a sample whose format isn't decoded.
+// Its data is kept as is, so that it's encoded byte for byte.
+type UnknownSample struct {
+	DataFormat DataFormat
+	Data       []byte
+}
+
+func (s UnknownSample) String() string {
+	type X UnknownSample
+	x := X(s)
+	return fmt.Sprintf("UnknownSample: %+v", x)
+}
+
+// SampleType returns the data format of the sample.
+func (s *UnknownSample) SampleType() DataFormat {
+	return s.DataFormat
+}
+
+// GetRecords returns nil, as the records of an unknown sample
+// aren't decoded.
+func (s *UnknownSample) GetRecords() []Record {
+	return nil
+}
+
+// Record panics, as an unknown sample has no records.
+func (s *UnknownSample) Record(i int) (Record, error) {
+	return s.GetRecords()[i], nil
+}
+
+// Validate returns nil, as an unknown sample isn't decoded.
+func (s *UnknownSample) Validate() error {
+	return nil
+}
+
+func decodeUnknownSample(format DataFormat, b []byte, prev Sample) (Sample, int, error) {
+	s, ok := prev.(*UnknownSample)
+	if !ok || s == nil {
+		s = &UnknownSample{}
+	}
+
+	s.DataFormat = format
+	s.Data = b
+
+	return s, len(b), nil
+}
+
+func (s *UnknownSample) encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, s.DataFormat)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint32(len(s.Data)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(s.Data)
+	return err
 }
@@ -0,0 +1,190 @@
+package sflow
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ValidationError is returned by Validate, and lists every
+// violation found.
+type ValidationError struct {
+	Violations []error
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Error()
+	}
+
+	return "sflow: invalid data: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return e.Violations
+}
+
+// violations collects the violations found by Validate.
+type violations []error
+
+func (v *violations) add(format string, args ...interface{}) {
+	*v = append(*v, fmt.Errorf(format, args...))
+}
+
+// addAll adds the violations of err, which is returned by
+// Validate, prefixed with the given context.
+func (v *violations) addAll(prefix string, err error) {
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		v.add("%s: %w", prefix, err)
+		return
+	}
+
+	for _, violation := range validationErr.Violations {
+		v.add("%s: %w", prefix, violation)
+	}
+}
+
+// addRecords adds the violations of the records of s. Records
+// kept by a lazy decoder are decoded.
+func (v *violations) addRecords(s Sample) {
+	for i := range s.GetRecords() {
+		rec, err := s.Record(i)
+		if err == nil {
+			err = validateRecord(rec)
+		}
+
+		if err != nil {
+			v.addAll(fmt.Sprintf("record %d", i), err)
+		}
+	}
+}
+
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Violations: v}
+}
+
+// validateRecord checks the fields of rec that depend on each other.
+func validateRecord(rec Record) error {
+	var v violations
+
+	// Records decoded into a datagram are pointers.
+	if r, ok := rec.(*RawPacketFlow); ok {
+		rec = *r
+	}
+
+	switch r := rec.(type) {
+	case RawPacketFlow:
+		if r.HeaderSize > r.FrameLength {
+			v.add("header size %d is more than frame length %d", r.HeaderSize, r.FrameLength)
+		}
+
+		if int(r.HeaderSize) != len(r.Header) {
+			v.add("header size %d doesn't match header length %d", r.HeaderSize, len(r.Header))
+		}
+	}
+
+	return v.err()
+}
+
+// Validate checks that the datagram is consistent, and returns a
+// *ValidationError listing every violation found, including those
+// of its samples. NumSamples is checked against len(Samples), so
+// datagrams decoded with skipped samples don't validate. The records
+// kept by a lazy decoder are decoded.
+func (d *Datagram) Validate() error {
+	var v violations
+
+	if d.Version != 5 {
+		v.add("unsupported version %d", d.Version)
+	}
+
+	switch d.IpVersion {
+	case AddressTypeUnknown:
+		if len(d.IpAddress) != 0 {
+			v.add("agent address %v of unknown type", d.IpAddress)
+		}
+
+	case AddressTypeIPv4:
+		if d.IpAddress.To4() == nil {
+			v.add("agent address %v isn't an IPv4 address", d.IpAddress)
+		}
+
+	case AddressTypeIPv6:
+		if len(d.IpAddress) != net.IPv6len {
+			v.add("agent address %v isn't an IPv6 address", d.IpAddress)
+		}
+
+	default:
+		v.add("unsupported agent address type %d", uint32(d.IpVersion))
+	}
+
+	if int(d.NumSamples) != len(d.Samples) {
+		v.add("%d samples, but NumSamples is %d", len(d.Samples), d.NumSamples)
+	}
+
+	type pool struct {
+		sample      int
+		sequenceNum uint32
+		samplePool  uint32
+	}
+
+	// The latest flow sample of each data source, whose
+	// sample pool only grows with its sequence number.
+	pools := map[DataSource]pool{}
+
+	for i, sample := range d.Samples {
+		if sample == nil {
+			v.add("sample %d is nil", i)
+			continue
+		}
+
+		err := sample.Validate()
+		if err != nil {
+			v.addAll(fmt.Sprintf("sample %d", i), err)
+		}
+
+		var source DataSource
+		var p pool
+
+		switch s := sample.(type) {
+		case *FlowSample:
+			source, p = s.Source, pool{i, s.SequenceNum, s.SamplePool}
+		case *ExpandedFlowSample:
+			source, p = s.Source, pool{i, s.SequenceNum, s.SamplePool}
+		default:
+			continue
+		}
+
+		prev, ok := pools[source]
+		if !ok {
+			pools[source] = p
+			continue
+		}
+
+		if !serialLess(prev.sequenceNum, p.sequenceNum) {
+			// Samples that are out of order aren't checked.
+			continue
+		}
+
+		if serialLess(p.samplePool, prev.samplePool) {
+			v.add("sample %d: sample pool %d is less than %d of sample %d",
+				i, p.samplePool, prev.samplePool, prev.sample)
+		}
+
+		pools[source] = p
+	}
+
+	return v.err()
+}
+
+// serialLess reports whether a precedes b, allowing for
+// the counters wrapping around.
+func serialLess(a, b uint32) bool {
+	return int32(b-a) > 0
+}
@@ -0,0 +1,113 @@
+package sflow
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidateDumps(t *testing.T) {
+	dumps := []string{
+		"_test/counter_sample.dump",
+		"_test/event_discarded_packet.dump",
+		"_test/flow_sample.dump",
+		"_test/flow_sample_3.dump",
+		"_test/flow_samples_2.dump",
+		"_test/host_sample.dump",
+	}
+
+	for _, dump := range dumps {
+		b, err := os.ReadFile(dump)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dgram, err := NewDecoder(bytes.NewReader(b)).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dgram.Validate()
+		if err != nil {
+			t.Errorf("%s: %v", dump, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	source := DataSource{Index: 3}
+
+	dgram := &Datagram{
+		Version:    5,
+		IpVersion:  3,
+		IpAddress:  net.IPv4(10, 0, 0, 1).To4(),
+		NumSamples: 4,
+		Samples: []Sample{
+			&FlowSample{
+				SequenceNum:  10,
+				Source:       source,
+				SamplingRate: 256,
+				SamplePool:   2560,
+			},
+			&FlowSample{
+				SequenceNum: 11,
+				Source:      source,
+				SamplePool:  2000,
+				Records: []Record{
+					RawPacketFlow{FrameLength: 64, HeaderSize: 128, Header: make([]byte, 128)},
+					RawPacketFlow{FrameLength: 64, HeaderSize: 32, Header: make([]byte, 16)},
+				},
+			},
+			&CounterSample{
+				Source:  source,
+				Records: []Record{HumidityCounters{Relative: 40}},
+			},
+		},
+	}
+
+	err := dgram.Validate()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a *ValidationError, got %v", err)
+	}
+
+	expected := []string{
+		"unsupported agent address type 3",
+		"3 samples, but NumSamples is 4",
+		"sample 1: sampling rate is zero",
+		"sample 1: record 0: header size 128 is more than frame length 64",
+		"sample 1: record 1: header size 32 doesn't match header length 16",
+		"sample 1: sample pool 2000 is less than 2560 of sample 0",
+	}
+
+	if len(validationErr.Violations) != len(expected) {
+		t.Fatalf("expected %d violations, got %v", len(expected), validationErr.Violations)
+	}
+
+	for i, v := range validationErr.Violations {
+		if !strings.Contains(v.Error(), expected[i]) {
+			t.Errorf("expected %q, got %q", expected[i], v)
+		}
+	}
+
+	// The counters wrap around.
+	dgram = &Datagram{
+		Version:    5,
+		IpVersion:  1,
+		IpAddress:  net.IPv4(10, 0, 0, 1).To4(),
+		NumSamples: 2,
+		Samples: []Sample{
+			&FlowSample{SequenceNum: 1<<32 - 1, Source: source, SamplingRate: 1, SamplePool: 1<<32 - 10},
+			&FlowSample{SequenceNum: 0, Source: source, SamplingRate: 1, SamplePool: 5},
+		},
+	}
+
+	err = dgram.Validate()
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}